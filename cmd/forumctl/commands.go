@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type cli struct {
+	uc      forum.ForumUsecase
+	printer printer
+	stdin   io.Reader
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "user":
+		return c.user(ctx, args[1:])
+	case "forum":
+		return c.forum(ctx, args[1:])
+	case "thread":
+		return c.thread(ctx, args[1:])
+	case "key":
+		return c.key(ctx, args[1:])
+	case "status":
+		return c.printer.status(c.uc.GetStatus())
+	case "clear":
+		return c.clear(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown command %q, run forumctl -h for help", args[0])
+}
+
+func (c *cli) user(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("user: expected get, create, update or admin")
+	}
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	fullname := flags.String("fullname", "", "full name")
+	email := flags.String("email", "", "email address")
+	about := flags.String("about", "", "free-form description")
+	password := flags.String("password", "", "password to log in with (create only)")
+	revoke := flags.Bool("revoke", false, "take the admin role away instead of granting it (admin only)")
+	nickname, err := parseSingleArg(flags, args[1:], "nickname")
+	if err != nil {
+		return err
+	}
+	user := models.User{Nickname: nickname, Fullname: *fullname, Email: *email, About: *about, Password: *password}
+
+	switch args[0] {
+	case "get":
+		result, err := c.uc.GetUser(ctx, nickname)
+		if err != nil {
+			return describe(err, "user "+nickname)
+		}
+		return c.printer.users([]models.User{result})
+	case "create":
+		result, err := c.uc.CreateUser(ctx, user)
+		if errors.Is(err, models.ErrorConflict) {
+			_ = c.printer.users(result)
+			return errors.New("user create: nickname or email already taken by the users above")
+		} else if err != nil {
+			return describe(err, "user "+nickname)
+		}
+		return c.printer.users(result)
+	case "update":
+		result, err := c.uc.UpdateUser(ctx, user)
+		if err != nil {
+			return describe(err, "user "+nickname)
+		}
+		return c.printer.users([]models.User{result})
+	case "admin":
+		if err := c.uc.SetAdmin(ctx, nickname, !*revoke); err != nil {
+			return describe(err, "user "+nickname)
+		}
+		if *revoke {
+			fmt.Printf("%s is no longer an admin\n", nickname)
+		} else {
+			fmt.Printf("%s is now an admin\n", nickname)
+		}
+		return nil
+	}
+	return fmt.Errorf("user: unknown subcommand %q", args[0])
+}
+
+func (c *cli) forum(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("forum: expected get or create")
+	}
+	flags := flag.NewFlagSet("forum "+args[0], flag.ContinueOnError)
+	title := flags.String("title", "", "forum title")
+	owner := flags.String("user", "", "nickname of the forum owner")
+	slug, err := parseSingleArg(flags, args[1:], "slug")
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "get":
+		result, err := c.uc.GetForum(ctx, slug)
+		if err != nil {
+			return describe(err, "forum "+slug)
+		}
+		return c.printer.forum(result)
+	case "create":
+		if *title == "" || *owner == "" {
+			return errors.New("forum create: -title and -user are required")
+		}
+		result, err := c.uc.CreateForum(ctx, models.Forum{Slug: slug, Title: *title, User: *owner})
+		if errors.Is(err, models.ErrorConflict) {
+			_ = c.printer.forum(result)
+			return errors.New("forum create: slug already taken by the forum above")
+		} else if errors.Is(err, models.ErrorNotFound) {
+			return fmt.Errorf("forum create: user %s not found", *owner)
+		} else if err != nil {
+			return describe(err, "forum "+slug)
+		}
+		return c.printer.forum(result)
+	}
+	return fmt.Errorf("forum: unknown subcommand %q", args[0])
+}
+
+func (c *cli) thread(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("thread: expected get or posts")
+	}
+	flags := flag.NewFlagSet("thread "+args[0], flag.ContinueOnError)
+	sort := flags.String("sort", "tree", "post order: flat, tree or parent_tree")
+	limit := flags.Int("limit", 100, "maximum number of posts (root posts for parent_tree)")
+	since := flags.String("since", "", "id of the post to continue after")
+	desc := flags.Bool("desc", false, "reverse order")
+	slugOrId, err := parseSingleArg(flags, args[1:], "slug_or_id")
+	if err != nil {
+		return err
+	}
+
+	thread, err := c.uc.CheckThreadByIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return describe(err, "thread "+slugOrId)
+	}
+
+	switch args[0] {
+	case "get":
+		return c.printer.thread(thread)
+	case "posts":
+		posts, err := c.uc.GetThreadPosts(ctx, strconv.Itoa(*limit), *since, strconv.FormatBool(*desc), *sort, thread.ID)
+		if err != nil {
+			return describe(err, "thread "+slugOrId)
+		}
+		return c.printer.posts(posts)
+	}
+	return fmt.Errorf("thread: unknown subcommand %q", args[0])
+}
+
+func (c *cli) key(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("key: expected create, list or revoke")
+	}
+	flags := flag.NewFlagSet("key "+args[0], flag.ContinueOnError)
+	name := flags.String("name", "", "what the key is for (create only)")
+	scopes := flags.String("scopes", "read", "comma-separated scopes: "+strings.Join(auth.ScopeNames, ", ")+" (create only)")
+	id := flags.String("id", "", "id of the key (revoke only)")
+	nickname, err := parseSingleArg(flags, args[1:], "nickname")
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "create":
+		key := models.APIKey{Name: *name, Scopes: strings.Split(*scopes, ",")}
+		result, err := c.uc.CreateAPIKey(ctx, nickname, key)
+		if err != nil {
+			return describe(err, "user "+nickname)
+		}
+		return c.printer.apiKeys([]models.APIKey{result})
+	case "list":
+		result, err := c.uc.GetAPIKeys(ctx, nickname)
+		if err != nil {
+			return describe(err, "user "+nickname)
+		}
+		return c.printer.apiKeys(result)
+	case "revoke":
+		if *id == "" {
+			return errors.New("key revoke: -id is required")
+		}
+		if err := c.uc.RevokeAPIKey(ctx, nickname, *id); err != nil {
+			return describe(err, "key "+*id+" of user "+nickname)
+		}
+		fmt.Printf("key %s revoked\n", *id)
+		return nil
+	}
+	return fmt.Errorf("key: unknown subcommand %q", args[0])
+}
+
+func (c *cli) clear(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("clear", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "skip the confirmation prompt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if !*yes {
+		status := c.uc.GetStatus()
+		fmt.Printf("This deletes %d users, %d forums, %d threads and %d posts.\nType \"yes\" to continue: ",
+			status.UsersCount, status.ForumsCount, status.ThreadsCount, status.PostsCount)
+		answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+		if strings.TrimSpace(answer) != "yes" {
+			return errors.New("clear: aborted")
+		}
+	}
+	if err := c.uc.Clear(ctx); err != nil {
+		return fmt.Errorf("clear: %w", err)
+	}
+	fmt.Println("database cleared")
+	return nil
+}
+
+func parseSingleArg(flags *flag.FlagSet, args []string, name string) (string, error) {
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected exactly one <%s> argument", flags.Name(), name)
+	}
+	return flags.Arg(0), nil
+}
+
+func describe(err error, what string) error {
+	switch {
+	case errors.Is(err, models.ErrorNotFound):
+		return fmt.Errorf("%s not found", what)
+	case errors.Is(err, models.ErrorConflict):
+		return fmt.Errorf("%s conflicts with existing data", what)
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
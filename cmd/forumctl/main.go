@@ -0,0 +1,108 @@
+// Command forumctl administers the forum database directly through the
+// repository and usecase packages, without going through the HTTP API.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum/repo"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum/usecase"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/webhooks"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `usage: forumctl [flags] <command> [command flags] [args]
+
+commands:
+  user get <nickname>
+  user create [-fullname ..] [-email ..] [-about ..] [-password ..] <nickname>
+  user update [-fullname ..] [-email ..] [-about ..] <nickname>
+  user admin [-revoke] <nickname>
+  forum get <slug>
+  forum create -title .. -user .. <slug>
+  thread get <slug_or_id>
+  thread posts [-sort flat|tree|parent_tree] [-limit n] [-since id] [-desc] <slug_or_id>
+  key create -name .. [-scopes read,post,..] <nickname>
+  key list <nickname>
+  key revoke -id .. <nickname>
+  status
+  clear [-yes]
+
+flags:
+`
+
+func main() {
+	flags := flag.NewFlagSet("forumctl", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	output := flags.String("output", "table", "output format: table or json")
+	loader := config.NewLoader(flags)
+	_ = flags.Parse(os.Args[1:])
+
+	if *output != "table" && *output != "json" {
+		fail(fmt.Errorf("unknown output format %q", *output))
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	poolConfig, err := cfg.Database.PoolConfig()
+	if err != nil {
+		fail(err)
+	}
+	poolConfig.MaxConns = 2
+	poolConfig.MinConns = 0
+	conn, err := pgxpool.ConnectConfig(ctx, poolConfig)
+	if err != nil {
+		fail(fmt.Errorf("connect to database: %w", err))
+	}
+	defer conn.Close()
+
+	forumRepo := repo.NewForumRepo(conn, cfg)
+	if err = forumRepo.LoadStatus(ctx); err != nil {
+		fail(fmt.Errorf("load status: %w", err))
+	}
+	// Writes made here queue webhook deliveries like the server's do; the
+	// server sends them.
+	var hooks *webhooks.Dispatcher
+	if cfg.Webhooks.Enabled {
+		hooks = webhooks.NewDispatcher(forumRepo, cfg.Webhooks)
+		if err = hooks.Refresh(ctx); err != nil {
+			fail(fmt.Errorf("load webhook subscriptions: %w", err))
+		}
+	}
+	cli := &cli{
+		uc:      usecase.NewForumUsecase(forumRepo, cfg, nil, hooks),
+		printer: newPrinter(*output, os.Stdout),
+		stdin:   os.Stdin,
+	}
+
+	// Whoever can reach the database can act as anyone anyway.
+	ctx = auth.NewContext(ctx, auth.Principal{Operator: true})
+	if err = cli.run(ctx, flags.Args()); err != nil {
+		conn.Close()
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "forumctl:", err)
+	os.Exit(1)
+}
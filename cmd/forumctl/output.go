@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type printer interface {
+	users(users []models.User) error
+	forum(forum models.Forum) error
+	thread(thread models.Thread) error
+	posts(posts []models.Post) error
+	status(status models.Status) error
+	apiKeys(keys []models.APIKey) error
+}
+
+func newPrinter(format string, out io.Writer) printer {
+	if format == "json" {
+		return jsonPrinter{out: out}
+	}
+	return tablePrinter{out: out}
+}
+
+type jsonPrinter struct {
+	out io.Writer
+}
+
+func (p jsonPrinter) print(v interface{}) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p jsonPrinter) users(users []models.User) error    { return p.print(users) }
+func (p jsonPrinter) forum(forum models.Forum) error     { return p.print(forum) }
+func (p jsonPrinter) thread(thread models.Thread) error  { return p.print(thread) }
+func (p jsonPrinter) posts(posts []models.Post) error    { return p.print(posts) }
+func (p jsonPrinter) status(status models.Status) error  { return p.print(status) }
+func (p jsonPrinter) apiKeys(keys []models.APIKey) error { return p.print(keys) }
+
+type tablePrinter struct {
+	out io.Writer
+}
+
+func (p tablePrinter) table(header string, rows func(w io.Writer)) error {
+	w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, header)
+	rows(w)
+	return w.Flush()
+}
+
+func (p tablePrinter) users(users []models.User) error {
+	return p.table("NICKNAME\tFULLNAME\tEMAIL\tABOUT", func(w io.Writer) {
+		for _, u := range users {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.Nickname, u.Fullname, u.Email, oneLine(u.About, 40))
+		}
+	})
+}
+
+func (p tablePrinter) forum(forum models.Forum) error {
+	return p.table("SLUG\tTITLE\tUSER\tTHREADS\tPOSTS", func(w io.Writer) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", forum.Slug, forum.Title, forum.User, forum.Threads, forum.Posts)
+	})
+}
+
+func (p tablePrinter) thread(thread models.Thread) error {
+	return p.table("ID\tSLUG\tFORUM\tAUTHOR\tVOTES\tCREATED\tTITLE", func(w io.Writer) {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\n", thread.ID, thread.Slug, thread.Forum, thread.Author,
+			thread.Votes, thread.Created.Format(time.RFC3339), oneLine(thread.Title, 40))
+	})
+}
+
+// posts indents every message by its depth in the reply tree; parents always
+// precede their children in tree orders, for flat order the depth is best effort.
+func (p tablePrinter) posts(posts []models.Post) error {
+	depth := make(map[int]int, len(posts))
+	return p.table("ID\tPARENT\tAUTHOR\tCREATED\tMESSAGE", func(w io.Writer) {
+		for _, post := range posts {
+			level := 0
+			if post.Parent != 0 {
+				level = depth[post.Parent] + 1
+			}
+			depth[post.ID] = level
+			fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s%s\n", post.ID, post.Parent, post.Author,
+				post.Created.Format(time.RFC3339), strings.Repeat("  ", level), oneLine(post.Message, 60))
+		}
+	})
+}
+
+func (p tablePrinter) status(status models.Status) error {
+	return p.table("USERS\tFORUMS\tTHREADS\tPOSTS", func(w io.Writer) {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\n", status.UsersCount, status.ForumsCount, status.ThreadsCount, status.PostsCount)
+	})
+}
+
+// apiKeys shows the key itself only right after it was issued.
+func (p tablePrinter) apiKeys(keys []models.APIKey) error {
+	return p.table("ID\tNAME\tNICKNAME\tSCOPES\tCREATED\tLAST USED\tKEY", func(w io.Writer) {
+		for _, key := range keys {
+			lastUsed := "never"
+			if key.LastUsed != nil {
+				lastUsed = key.LastUsed.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, oneLine(key.Name, 30), key.Nickname,
+				strings.Join(key.Scopes, ","), key.Created.Format(time.RFC3339), lastUsed, key.Key)
+		}
+	})
+}
+
+func oneLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) > max {
+		return string([]rune(s)[:max-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/db"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/migrate"
+	"os"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: main [flags] migrate up|down|status|redo"
+
+func runMigrate(ctx context.Context, conn *pgxpool.Pool, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	migrator, err := migrate.New(conn, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Printf("schema is up to date at version %d\n", migrator.Latest())
+		}
+		return err
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+	case "redo":
+		m, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redone %04d_%s\n", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
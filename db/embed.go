@@ -0,0 +1,9 @@
+package db
+
+import "embed"
+
+// Migrations holds the versioned schema, named NNNN_name.up.sql and
+// NNNN_name.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
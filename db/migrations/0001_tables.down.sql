@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS user_forum;
+DROP TABLE IF EXISTS vote;
+DROP TABLE IF EXISTS post;
+DROP TABLE IF EXISTS thread;
+DROP TABLE IF EXISTS forum;
+DROP TABLE IF EXISTS "user";
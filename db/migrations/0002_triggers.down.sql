@@ -0,0 +1,17 @@
+DROP TRIGGER IF EXISTS on_insert_post ON "post";
+DROP FUNCTION IF EXISTS updatePath();
+
+DROP TRIGGER IF EXISTS e_voice ON vote;
+DROP FUNCTION IF EXISTS updateVotes();
+
+DROP TRIGGER IF EXISTS a_voice ON vote;
+DROP FUNCTION IF EXISTS insertVotes();
+
+DROP TRIGGER IF EXISTS a_t_i_forum ON thread;
+DROP FUNCTION IF EXISTS ThreadsCountInc();
+
+DROP TRIGGER IF EXISTS t_i_forum_users ON "thread";
+DROP FUNCTION IF EXISTS updateThreadUserForum();
+
+DROP TRIGGER IF EXISTS p_i_user_forum ON "post";
+DROP FUNCTION IF EXISTS updatePostUserForum();
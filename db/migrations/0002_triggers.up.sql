@@ -1,72 +1,3 @@
-CREATE EXTENSION IF NOT EXISTS CITEXT;
-
-CREATE UNLOGGED TABLE "user"
-(
-    Nickname CITEXT COLLATE "C" PRIMARY KEY,
-    FullName TEXT NOT NULL,
-    About    TEXT NOT NULL DEFAULT '',
-    Email    CITEXT COLLATE "C" UNIQUE
-);
-
-CREATE UNLOGGED TABLE forum
-(
-    Title   TEXT NOT NULL,
-    "user"  CITEXT COLLATE "C",
-    Slug    CITEXT COLLATE "C" PRIMARY KEY,
-    Posts   INT DEFAULT 0,
-    Threads INT DEFAULT 0
-);
-
-CREATE UNLOGGED TABLE thread
-(
-    Id      SERIAL PRIMARY KEY,
-    Title   TEXT NOT NULL,
-    Author  CITEXT COLLATE "C" REFERENCES "user" (Nickname),
-    Forum   CITEXT COLLATE "C" REFERENCES "forum" (Slug),
-    Message TEXT NOT NULL,
-    Votes   INT                      DEFAULT 0,
-    Slug    CITEXT COLLATE "C",
-    Created TIMESTAMP WITH TIME ZONE DEFAULT now()
-);
-
-CREATE UNLOGGED TABLE post
-(
-    Id       SERIAL PRIMARY KEY,
-    Author   CITEXT COLLATE "C",
-    Created  TIMESTAMP WITH TIME ZONE DEFAULT now(),
-    Forum    CITEXT COLLATE "C",
-    IsEdited BOOLEAN                  DEFAULT FALSE,
-    Message  CITEXT COLLATE "C" NOT NULL,
-    Parent   INT                      DEFAULT 0,
-    Thread   INT,
-    Path     INTEGER[],
-    FOREIGN KEY (thread) REFERENCES "thread" (id),
-    FOREIGN KEY (author) REFERENCES "user" (nickname)
-);
-
-CREATE UNLOGGED TABLE vote
-(
-    ID     SERIAL PRIMARY KEY,
-    Author CITEXT COLLATE "C" REFERENCES "user" (Nickname),
-    Voice  INT NOT NULL,
-    Thread INT,
-    FOREIGN KEY (thread) REFERENCES "thread" (id),
-    UNIQUE (Author, Thread)
-);
-
-
-CREATE UNLOGGED TABLE user_forum
-(
-    Nickname CITEXT NOT NULL,
-    FullName TEXT   NOT NULL,
-    About    TEXT,
-    Email    CITEXT,
-    Slug     CITEXT NOT NULL,
-    FOREIGN KEY (Nickname) REFERENCES "user" (Nickname),
-    FOREIGN KEY (Slug) REFERENCES "forum" (Slug),
-    UNIQUE (Nickname, Slug)
-);
-
 CREATE OR REPLACE FUNCTION updatePostUserForum() RETURNS TRIGGER AS
 $update_forum_posts$
 DECLARE
@@ -82,6 +13,7 @@ BEGIN
 end
 $update_forum_posts$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS p_i_user_forum ON "post";
 CREATE TRIGGER p_i_user_forum
     AFTER INSERT
     ON "post"
@@ -108,6 +40,7 @@ BEGIN
 end
 $update_forum_threads$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS t_i_forum_users ON "thread";
 CREATE TRIGGER t_i_forum_users
     AFTER INSERT
     ON "thread"
@@ -122,6 +55,7 @@ BEGIN
 end
 $update_forums$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS a_t_i_forum ON thread;
 CREATE TRIGGER a_t_i_forum
     BEFORE INSERT
     ON thread
@@ -136,6 +70,7 @@ BEGIN
 end
 $update_vote$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS a_voice ON vote;
 CREATE TRIGGER a_voice
     BEFORE INSERT
     ON vote
@@ -152,6 +87,7 @@ BEGIN
 end
 $update_votes$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS e_voice ON vote;
 CREATE TRIGGER e_voice
     BEFORE UPDATE
     ON vote
@@ -174,27 +110,9 @@ BEGIN
 END
 $update_path$ LANGUAGE plpgsql;
 
+DROP TRIGGER IF EXISTS on_insert_post ON "post";
 CREATE TRIGGER on_insert_post
     BEFORE INSERT
     ON "post"
     FOR EACH ROW
 EXECUTE PROCEDURE updatePath();
-
-CREATE INDEX IF NOT EXISTS users_nickname_index ON "user" USING hash (nickname);
-
-CREATE INDEX IF NOT EXISTS forum_slug_index ON forum USING hash (slug);
-
-CREATE INDEX IF NOT EXISTS thread_slug_index ON thread USING hash (slug);
-CREATE INDEX IF NOT EXISTS thread_forum_date_index ON thread (forum, created);
-
-CREATE UNIQUE INDEX IF NOT EXISTS forum_users_index ON user_forum (slug, nickname);
-
-CREATE UNIQUE INDEX IF NOT EXISTS vote_index ON vote (author, thread);
-
-CREATE INDEX IF NOT EXISTS post_id_index ON post USING hash (id);
-CREATE INDEX IF NOT EXISTS post_thread_path_id_index ON post (thread, path, id);
-CREATE INDEX IF NOT EXISTS post_thread_id_path_parent_index ON post (thread, id, (path[1]), parent);
-CREATE INDEX IF NOT EXISTS post_path_index ON post ((path[1]));
-
-VACUUM;
-VACUUM ANALYSE;
\ No newline at end of file
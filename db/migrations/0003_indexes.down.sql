@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS post_path_index;
+DROP INDEX IF EXISTS post_thread_id_path_parent_index;
+DROP INDEX IF EXISTS post_thread_path_id_index;
+DROP INDEX IF EXISTS post_id_index;
+
+DROP INDEX IF EXISTS vote_index;
+
+DROP INDEX IF EXISTS forum_users_index;
+
+DROP INDEX IF EXISTS thread_forum_date_index;
+DROP INDEX IF EXISTS thread_slug_index;
+
+DROP INDEX IF EXISTS forum_slug_index;
+
+DROP INDEX IF EXISTS users_nickname_index;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS u_post_modified ON "post";
+DROP TRIGGER IF EXISTS u_thread_modified ON thread;
+DROP FUNCTION IF EXISTS setModified();
+
+ALTER TABLE post DROP COLUMN IF EXISTS Modified;
+ALTER TABLE thread DROP COLUMN IF EXISTS Modified;
@@ -0,0 +1,28 @@
+ALTER TABLE thread ADD COLUMN IF NOT EXISTS Modified TIMESTAMP WITH TIME ZONE;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS Modified TIMESTAMP WITH TIME ZONE;
+
+-- Modified stays NULL until the row actually changes, so Created is the last
+-- modification of a row that was never edited.
+CREATE OR REPLACE FUNCTION setModified() RETURNS TRIGGER AS
+$set_modified$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD THEN
+        NEW.Modified = now();
+    END IF;
+    return NEW;
+end
+$set_modified$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS u_thread_modified ON thread;
+CREATE TRIGGER u_thread_modified
+    BEFORE UPDATE
+    ON thread
+    FOR EACH ROW
+EXECUTE PROCEDURE setModified();
+
+DROP TRIGGER IF EXISTS u_post_modified ON "post";
+CREATE TRIGGER u_post_modified
+    BEFORE UPDATE
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE setModified();
@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS u_post_version ON "post";
+DROP TRIGGER IF EXISTS u_thread_version ON thread;
+DROP TRIGGER IF EXISTS u_user_version ON "user";
+DROP FUNCTION IF EXISTS bumpVersion();
+
+ALTER TABLE post DROP COLUMN IF EXISTS Version;
+ALTER TABLE thread DROP COLUMN IF EXISTS Version;
+ALTER TABLE "user" DROP COLUMN IF EXISTS Version;
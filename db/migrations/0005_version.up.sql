@@ -0,0 +1,36 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS Version INT NOT NULL DEFAULT 1;
+ALTER TABLE thread ADD COLUMN IF NOT EXISTS Version INT NOT NULL DEFAULT 1;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS Version INT NOT NULL DEFAULT 1;
+
+-- Every change to a row bumps its version, including the vote counter
+-- maintained by the vote triggers, so If-Match sees any concurrent write.
+CREATE OR REPLACE FUNCTION bumpVersion() RETURNS TRIGGER AS
+$bump_version$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD THEN
+        NEW.Version = OLD.Version + 1;
+    END IF;
+    return NEW;
+end
+$bump_version$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS u_user_version ON "user";
+CREATE TRIGGER u_user_version
+    BEFORE UPDATE
+    ON "user"
+    FOR EACH ROW
+EXECUTE PROCEDURE bumpVersion();
+
+DROP TRIGGER IF EXISTS u_thread_version ON thread;
+CREATE TRIGGER u_thread_version
+    BEFORE UPDATE
+    ON thread
+    FOR EACH ROW
+EXECUTE PROCEDURE bumpVersion();
+
+DROP TRIGGER IF EXISTS u_post_version ON "post";
+CREATE TRIGGER u_post_version
+    BEFORE UPDATE
+    ON "post"
+    FOR EACH ROW
+EXECUTE PROCEDURE bumpVersion();
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS thread_forum_created_id_index;
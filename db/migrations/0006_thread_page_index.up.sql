@@ -0,0 +1,3 @@
+-- /api/v2 pages threads by (created, id) so rows sharing a created time keep
+-- a strict order.
+CREATE INDEX IF NOT EXISTS thread_forum_created_id_index ON thread (forum, created, id);
@@ -0,0 +1,37 @@
+-- Webhook subscriptions and their delivery queue. Unlike the forum data
+-- these tables are logged, so queued deliveries survive a crash; a logged
+-- table cannot reference an unlogged one, hence no foreign key to forum.
+CREATE TABLE IF NOT EXISTS webhook
+(
+    Id      SERIAL PRIMARY KEY,
+    Forum   CITEXT COLLATE "C"       NOT NULL,
+    Url     TEXT                     NOT NULL,
+    Secret  TEXT                     NOT NULL,
+    Events  TEXT[]                   NOT NULL,
+    Active  BOOLEAN                  NOT NULL DEFAULT TRUE,
+    Created TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_forum_index ON webhook (Forum);
+
+-- Status is pending until a delivery succeeds (delivered) or runs out of
+-- attempts (dead). A pending row is due at NextAttempt; claiming it pushes
+-- NextAttempt past the delivery timeout, so a row claimed by an instance
+-- that died is picked up again.
+CREATE TABLE IF NOT EXISTS webhook_delivery
+(
+    Id          BIGSERIAL PRIMARY KEY,
+    Webhook     INT                      NOT NULL REFERENCES webhook (Id) ON DELETE CASCADE,
+    Event       TEXT                     NOT NULL,
+    Payload     JSON                     NOT NULL,
+    Status      TEXT                     NOT NULL DEFAULT 'pending',
+    Attempts    INT                      NOT NULL DEFAULT 0,
+    NextAttempt TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    LastStatus  INT,
+    LastError   TEXT,
+    Created     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    Delivered   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_index ON webhook_delivery (NextAttempt) WHERE Status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_index ON webhook_delivery (Webhook, Id);
@@ -0,0 +1,18 @@
+-- Responses kept for requests retried with an Idempotency-Key. Status is
+-- NULL while the first request with the key is being handled. The table is
+-- unlogged like the forum data: a crash that loses the writes must lose the
+-- responses describing them too, or a retry would be told about posts that
+-- are gone.
+CREATE UNLOGGED TABLE IF NOT EXISTS idempotency_key
+(
+    Scope       TEXT                     NOT NULL,
+    Key         TEXT                     NOT NULL,
+    RequestHash BYTEA                    NOT NULL,
+    Status      INT,
+    Headers     JSONB,
+    Body        BYTEA,
+    Created     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (Scope, Key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_key_created_index ON idempotency_key (Created);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_session;
+ALTER TABLE "user" DROP COLUMN IF EXISTS PasswordHash;
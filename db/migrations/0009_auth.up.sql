@@ -0,0 +1,15 @@
+-- bcrypt hash of the password; users created without one cannot log in.
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS PasswordHash TEXT;
+
+-- A login. The refresh token is stored hashed and replaced every time it is
+-- used; logging out deletes the session.
+CREATE UNLOGGED TABLE IF NOT EXISTS user_session
+(
+    Id          TEXT PRIMARY KEY,
+    Nickname    CITEXT COLLATE "C"       NOT NULL REFERENCES "user" (Nickname) ON DELETE CASCADE,
+    RefreshHash BYTEA                    NOT NULL UNIQUE,
+    Created     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    Expires     TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS user_session_expires_index ON user_session (Expires);
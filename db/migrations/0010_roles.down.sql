@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS forum_moderator;
+ALTER TABLE "user" DROP COLUMN IF EXISTS IsAdmin;
@@ -0,0 +1,12 @@
+-- Admins may do anything, including clearing the database. Granted with
+-- forumctl user admin.
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS IsAdmin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Moderators may edit every thread and post of a forum. The owner of a
+-- forum is its "user" column and needs no row here.
+CREATE UNLOGGED TABLE IF NOT EXISTS forum_moderator
+(
+    Forum    CITEXT COLLATE "C" NOT NULL REFERENCES forum (Slug) ON DELETE CASCADE,
+    Nickname CITEXT COLLATE "C" NOT NULL REFERENCES "user" (Nickname) ON DELETE CASCADE,
+    PRIMARY KEY (Forum, Nickname)
+);
@@ -0,0 +1,19 @@
+-- A long-lived credential of a bot or an importer. It acts as Nickname,
+-- limited to Scopes; only the hash of the key is stored. Revoking a key
+-- deletes it.
+-- Unlike the user table this one is logged, so keys survive a crash; a
+-- logged table cannot reference an unlogged one, hence no foreign key to
+-- "user". A key whose user is gone does not authenticate, and creating a
+-- user deletes the keys left behind under its nickname.
+CREATE TABLE IF NOT EXISTS api_key
+(
+    Id       TEXT PRIMARY KEY,
+    Name     TEXT                     NOT NULL,
+    Nickname CITEXT COLLATE "C"       NOT NULL,
+    Scopes   TEXT[]                   NOT NULL,
+    KeyHash  BYTEA                    NOT NULL UNIQUE,
+    Created  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    LastUsed TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS api_key_nickname_index ON api_key (Nickname);
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// APIKeyPrefix starts every API key, which tells keys from access tokens
+// in the Authorization header.
+const APIKeyPrefix = "fk_"
+
+// NewAPIKey returns a random API key and the hash to store.
+func NewAPIKey() (string, []byte) {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	encoded := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(key)
+	return encoded, HashAPIKey(encoded)
+}
+
+// HashAPIKey is the stored form of an API key, hashed like refresh tokens.
+func HashAPIKey(key string) []byte {
+	return HashRefreshToken(key)
+}
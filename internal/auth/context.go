@@ -0,0 +1,31 @@
+package auth
+
+import "context"
+
+// Principal is who a request acts as. Session is set for logins and APIKey
+// for API keys. Operator marks the trusted local tools such as forumctl,
+// which act on behalf of any user.
+type Principal struct {
+	Nickname string
+	Session  string
+	APIKey   string
+	Scopes   Scope
+	Operator bool
+}
+
+// Can reports whether the principal holds every scope in s.
+func (p Principal) Can(s Scope) bool {
+	return p.Operator || p.Scopes&s == s
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"sync"
+)
+
+// Passwords hashes and checks passwords with bcrypt.
+type Passwords struct {
+	cost int
+
+	once sync.Once
+	// dummy is compared against when a user has no password, so a login
+	// takes as long whether or not the nickname exists.
+	dummy []byte
+}
+
+func NewPasswords(cost int) *Passwords {
+	return &Passwords{cost: cost}
+}
+
+func (p *Passwords) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.cost)
+	return string(hash), err
+}
+
+// Check reports whether password matches hash; an empty hash matches
+// nothing.
+func (p *Passwords) Check(hash, password string) bool {
+	if hash == "" {
+		p.once.Do(func() {
+			p.dummy, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), p.cost)
+		})
+		_ = bcrypt.CompareHashAndPassword(p.dummy, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,13 @@
+package auth
+
+// Role is the set of roles a user holds towards a forum. Every signed-in
+// user is a member; admins hold their role everywhere, owners and
+// moderators only in their forum.
+type Role uint8
+
+const (
+	RoleMember Role = 1 << iota
+	RoleModerator
+	RoleOwner
+	RoleAdmin
+)
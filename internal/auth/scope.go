@@ -0,0 +1,46 @@
+package auth
+
+import "strings"
+
+// Scope is the set of things a request may do. Sessions hold every scope;
+// an API key only the ones it was issued with, on top of the roles of the
+// user it acts as.
+type Scope uint8
+
+const (
+	ScopeRead Scope = 1 << iota
+	ScopePost
+	ScopeVote
+	ScopeModerate
+	ScopeAdmin
+
+	AllScopes = ScopeRead | ScopePost | ScopeVote | ScopeModerate | ScopeAdmin
+)
+
+// ScopeNames are the names of the scopes in the API, in bit order.
+var ScopeNames = []string{"read", "post", "vote", "moderate", "admin"}
+
+// ParseScope returns the scope of a name and whether the name is known.
+func ParseScope(name string) (Scope, bool) {
+	for i, known := range ScopeNames {
+		if name == known {
+			return 1 << i, true
+		}
+	}
+	return 0, false
+}
+
+// Names returns the names of the scopes in s.
+func (s Scope) Names() []string {
+	names := make([]string, 0, len(ScopeNames))
+	for i, name := range ScopeNames {
+		if s&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (s Scope) String() string {
+	return strings.Join(s.Names(), ", ")
+}
@@ -0,0 +1,111 @@
+// Package auth issues and verifies the tokens of user sessions, hashes
+// passwords and carries the authenticated user in request contexts.
+//
+// An access token has the format of the page cursors:
+// base64url(JSON claims) "." base64url(HMAC-SHA256). It is checked without
+// a database lookup, so it stays valid until it expires even after its
+// session is logged out; keep the access TTL short. Refresh tokens and API
+// keys are random and only their hash is stored.
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+type claims struct {
+	Subject string `json:"sub"`
+	Session string `json:"sid"`
+	Expires int64  `json:"exp"`
+}
+
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokens signs with secret, or with a random key when it is empty, and
+// issues access tokens valid for ttl.
+func NewTokens(secret string, ttl time.Duration) *Tokens {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &Tokens{secret: key, ttl: ttl, now: time.Now}
+}
+
+// Issue returns an access token of the principal's session and its expiry.
+func (t *Tokens) Issue(p Principal) (string, time.Time) {
+	expires := t.now().Add(t.ttl)
+	payload, _ := json.Marshal(claims{Subject: p.Nickname, Session: p.Session, Expires: expires.Unix()})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(t.mac(payload)), expires
+}
+
+// Verify returns the principal an access token was issued to.
+func (t *Tokens) Verify(token string) (Principal, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return Principal{}, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Principal{}, ErrInvalidToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, t.mac(payload)) {
+		return Principal{}, ErrInvalidToken
+	}
+
+	var c claims
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&c); err != nil || c.Subject == "" {
+		return Principal{}, ErrInvalidToken
+	}
+	if t.now().Unix() >= c.Expires {
+		return Principal{}, ErrExpiredToken
+	}
+	return Principal{Nickname: c.Subject, Session: c.Session, Scopes: AllScopes}, nil
+}
+
+func (t *Tokens) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, t.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// NewRefreshToken returns a random refresh token and the hash to store.
+func NewRefreshToken() (string, []byte) {
+	token := make([]byte, 32)
+	_, _ = rand.Read(token)
+	encoded := base64.RawURLEncoding.EncodeToString(token)
+	return encoded, HashRefreshToken(encoded)
+}
+
+// HashRefreshToken is the stored form of a refresh token. The tokens are
+// random, so a plain hash is enough to make a leaked table useless.
+func HashRefreshToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// NewSessionID returns a random session id.
+func NewSessionID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return base64.RawURLEncoding.EncodeToString(id)
+}
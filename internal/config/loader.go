@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "FORUM_"
+
+type setting struct {
+	name  string
+	usage string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"db-dsn", "PostgreSQL connection string", func(cfg *Config, v string) error {
+		cfg.Database.DSN = v
+		return nil
+	}},
+	{"db-max-conns", "maximum number of pooled connections", func(cfg *Config, v string) error {
+		return parseInt32(v, &cfg.Database.MaxConns)
+	}},
+	{"db-min-conns", "minimum number of pooled connections", func(cfg *Config, v string) error {
+		return parseInt32(v, &cfg.Database.MinConns)
+	}},
+	{"db-max-conn-lifetime", "maximum lifetime of a pooled connection", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Database.MaxConnLifetime)
+	}},
+	{"db-connect-timeout", "timeout for establishing a database connection", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Database.ConnectTimeout)
+	}},
+	{"http-addr", "address the HTTP server listens on", func(cfg *Config, v string) error {
+		cfg.HTTP.Addr = v
+		return nil
+	}},
+	{"http-read-timeout", "maximum duration for reading a whole request", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.ReadTimeout)
+	}},
+	{"http-read-header-timeout", "maximum duration for reading request headers", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.ReadHeaderTimeout)
+	}},
+	{"http-write-timeout", "maximum duration before timing out a response write", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.WriteTimeout)
+	}},
+	{"http-idle-timeout", "keep-alive idle timeout", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.IdleTimeout)
+	}},
+	{"http-request-timeout", "deadline for handling a single request, 0 disables it", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.RequestTimeout)
+	}},
+	{"http-shutdown-delay", "time between reporting not-ready and draining connections", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.ShutdownDelay)
+	}},
+	{"http-drain-timeout", "time given to in-flight requests on shutdown", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.HTTP.DrainTimeout)
+	}},
+	{"pagination-default-limit", "page size used when a list request has no limit", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Pagination.DefaultLimit)
+	}},
+	{"pagination-max-limit", "largest limit a list request may ask for", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Pagination.MaxLimit)
+	}},
+	{"pagination-max-page-size", "most rows a single list response may hold", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Pagination.MaxPageSize)
+	}},
+	{"pagination-cursor-secret", "key signing /api/v2 page cursors, random per process when empty", func(cfg *Config, v string) error {
+		cfg.Pagination.CursorSecret = v
+		return nil
+	}},
+	{"health-check-timeout", "time budget for the readiness checks", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Health.CheckTimeout)
+	}},
+	{"health-max-pool-saturation", "acquired/max connections ratio at which the instance reports not ready", func(cfg *Config, v string) error {
+		return parseFloat(v, &cfg.Health.MaxPoolSaturation)
+	}},
+	{"compression-enabled", "compress responses with gzip or brotli when the client accepts it", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Compression.Enabled)
+	}},
+	{"compression-min-size", "smallest response body in bytes worth compressing", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Compression.MinSize)
+	}},
+	{"compression-gzip-level", "gzip level from 1 (fastest) to 9 (smallest)", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Compression.GzipLevel)
+	}},
+	{"compression-brotli-quality", "brotli quality from 0 (fastest) to 11 (smallest)", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Compression.BrotliQuality)
+	}},
+	{"compression-content-types", "comma-separated media types eligible for compression", func(cfg *Config, v string) error {
+		cfg.Compression.ContentTypes = parseList(v)
+		return nil
+	}},
+	{"ratelimit-enabled", "throttle the routes that have a rate limit policy", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.RateLimit.Enabled)
+	}},
+	{"ratelimit-trust-forwarded-for", "take the client IP from X-Forwarded-For", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.RateLimit.TrustForwardedFor)
+	}},
+	{"ratelimit-eviction-interval", "how often refilled rate limit buckets are dropped", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.RateLimit.EvictionInterval)
+	}},
+	{"ratelimit-policies", `comma-separated "METHOD /route/template=rate:burst" entries replacing the default policies`, func(cfg *Config, v string) error {
+		return parsePolicies(v, &cfg.RateLimit.Policies)
+	}},
+	{"graphql-enabled", "serve the GraphQL API on /api/graphql", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.GraphQL.Enabled)
+	}},
+	{"graphql-max-depth", "deepest selection nesting a GraphQL query may use", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.GraphQL.MaxDepth)
+	}},
+	{"graphql-max-complexity", "cost budget of one GraphQL query: objects cost 1, lists their limit", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.GraphQL.MaxComplexity)
+	}},
+	{"graphql-max-query-length", "longest GraphQL query document in bytes", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.GraphQL.MaxQueryLength)
+	}},
+	{"graphql-introspection", "answer GraphQL introspection queries", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.GraphQL.Introspection)
+	}},
+	{"grpc-enabled", "serve the gRPC API", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.GRPC.Enabled)
+	}},
+	{"grpc-addr", "host:port the gRPC server listens on", func(cfg *Config, v string) error {
+		cfg.GRPC.Addr = v
+		return nil
+	}},
+	{"events-enabled", "stream thread changes on /api/thread/{slug_or_id}/events", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Events.Enabled)
+	}},
+	{"events-history", "events kept per watched thread for Last-Event-ID resume", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Events.History)
+	}},
+	{"events-buffer", "events a subscriber may fall behind before it is disconnected", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Events.Buffer)
+	}},
+	{"events-keep-alive", "interval of keep-alive comments and pings on idle event streams", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Events.KeepAlive)
+	}},
+	{"events-retention", "how long the events of a thread are kept after its last subscriber leaves", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Events.Retention)
+	}},
+	{"webhooks-enabled", "send webhook deliveries and serve /api/forum/{slug}/webhooks", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Webhooks.Enabled)
+	}},
+	{"webhooks-workers", "webhook deliveries sent at the same time", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Webhooks.Workers)
+	}},
+	{"webhooks-poll-interval", "how often the webhook queue is checked for due deliveries", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Webhooks.PollInterval)
+	}},
+	{"webhooks-timeout", "time limit of one webhook delivery attempt", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Webhooks.Timeout)
+	}},
+	{"webhooks-max-attempts", "attempts before a webhook delivery is dead-lettered", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Webhooks.MaxAttempts)
+	}},
+	{"webhooks-backoff-base", "wait before the first webhook delivery retry", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Webhooks.BackoffBase)
+	}},
+	{"webhooks-backoff-max", "longest wait between webhook delivery retries", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Webhooks.BackoffMax)
+	}},
+	{"webhooks-retention", "how long finished webhook deliveries stay in the log", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Webhooks.Retention)
+	}},
+	{"webhooks-allow-private-networks", "let webhooks target loopback and private addresses", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Webhooks.AllowPrivateNetworks)
+	}},
+	{"idempotency-enabled", "replay responses to retried writes sent with an Idempotency-Key header", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Idempotency.Enabled)
+	}},
+	{"idempotency-ttl", "how long the response to an idempotency key is kept", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Idempotency.TTL)
+	}},
+	{"idempotency-lease", "how long an unfinished request holds its idempotency key", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Idempotency.Lease)
+	}},
+	{"idempotency-max-body-size", "largest request body in bytes accepted with an Idempotency-Key", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Idempotency.MaxBodySize)
+	}},
+	{"idempotency-secret", "key of the stored idempotency request hashes, random per process when empty", func(cfg *Config, v string) error {
+		cfg.Idempotency.Secret = v
+		return nil
+	}},
+	{"auth-enabled", "require a bearer token of the acting user on writes", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Auth.Enabled)
+	}},
+	{"auth-secret", "key signing access tokens, at least 32 characters", func(cfg *Config, v string) error {
+		cfg.Auth.Secret = v
+		return nil
+	}},
+	{"auth-access-ttl", "lifetime of an access token", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Auth.AccessTTL)
+	}},
+	{"auth-refresh-ttl", "lifetime of a refresh token", func(cfg *Config, v string) error {
+		return parseDuration(v, &cfg.Auth.RefreshTTL)
+	}},
+	{"auth-bcrypt-cost", "bcrypt cost of password hashes", func(cfg *Config, v string) error {
+		return parseInt(v, &cfg.Auth.BcryptCost)
+	}},
+	{"features-allow-clear", "enable POST /api/service/clear", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Features.AllowClear)
+	}},
+	{"features-metrics", "expose Prometheus metrics on /metrics", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Features.Metrics)
+	}},
+	{"features-validate-requests", "reject requests that do not match the OpenAPI document", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Features.ValidateRequests)
+	}},
+	{"features-validate-responses", "replace responses that do not match the OpenAPI document with 500 (tests only)", func(cfg *Config, v string) error {
+		return parseBool(v, &cfg.Features.ValidateResponses)
+	}},
+}
+
+// Loader registers the config flags on a FlagSet and assembles the final
+// Config once the FlagSet has been parsed.
+type Loader struct {
+	fs         *flag.FlagSet
+	configFile *string
+	values     map[string]*string
+}
+
+func NewLoader(fs *flag.FlagSet) *Loader {
+	l := &Loader{
+		fs:         fs,
+		configFile: fs.String("config", "", "path to a JSON config file (env "+envName("config")+")"),
+		values:     make(map[string]*string, len(settings)),
+	}
+	for _, s := range settings {
+		l.values[s.name] = fs.String(s.name, "", fmt.Sprintf("%s (env %s)", s.usage, envName(s.name)))
+	}
+	return l
+}
+
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+	var problems []string
+
+	path := *l.configFile
+	if path == "" {
+		path = os.Getenv(envName("config"))
+	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			problems = append(problems, fmt.Sprintf("config file %s: %v", path, err))
+		}
+	}
+
+	for _, s := range settings {
+		value, ok := os.LookupEnv(envName(s.name))
+		if !ok {
+			continue
+		}
+		if err := s.apply(cfg, value); err != nil {
+			problems = append(problems, fmt.Sprintf("env %s: %v", envName(s.name), err))
+		}
+	}
+
+	passed := make(map[string]bool)
+	l.fs.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	for _, s := range settings {
+		if !passed[s.name] {
+			continue
+		}
+		if err := s.apply(cfg, *l.values[s.name]); err != nil {
+			problems = append(problems, fmt.Sprintf("flag -%s: %v", s.name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(cfg)
+}
+
+func envName(name string) string {
+	result := []byte(envPrefix)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '-':
+			c = '_'
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		result = append(result, c)
+	}
+	return string(result)
+}
+
+func parseInt(v string, dst *int) error {
+	parsed, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", v)
+	}
+	*dst = parsed
+	return nil
+}
+
+func parseInt32(v string, dst *int32) error {
+	parsed, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%q is not a 32-bit integer", v)
+	}
+	*dst = int32(parsed)
+	return nil
+}
+
+func parseFloat(v string, dst *float64) error {
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", v)
+	}
+	*dst = parsed
+	return nil
+}
+
+func parseBool(v string, dst *bool) error {
+	parsed, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", v)
+	}
+	*dst = parsed
+	return nil
+}
+
+func parseList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func parsePolicies(v string, dst *map[string]RateLimitPolicy) error {
+	policies := make(map[string]RateLimitPolicy)
+	for _, item := range parseList(v) {
+		route, spec, ok := strings.Cut(item, "=")
+		rate, burst, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 {
+			return fmt.Errorf("%q is not METHOD /route=rate:burst", item)
+		}
+		var policy RateLimitPolicy
+		if err := parseFloat(rate, &policy.Rate); err != nil {
+			return err
+		}
+		if err := parseInt(burst, &policy.Burst); err != nil {
+			return err
+		}
+		policies[strings.TrimSpace(route)] = policy
+	}
+	*dst = policies
+	return nil
+}
+
+func parseDuration(v string, dst *Duration) error {
+	parsed, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%q is not a duration", v)
+	}
+	*dst = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+	"net"
+	"sort"
+	"strings"
+)
+
+const minAuthSecretLength = 32
+
+// Validate reports every inconsistent setting at once instead of stopping at
+// the first one.
+func (c *Config) Validate() error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Database.DSN == "" {
+		addf("database.dsn: must not be empty")
+	} else if _, err := pgxpool.ParseConfig(c.Database.DSN); err != nil {
+		addf("database.dsn: %v", err)
+	}
+	if c.Database.MaxConns < 1 {
+		addf("database.max_conns: must be at least 1, got %d", c.Database.MaxConns)
+	}
+	if c.Database.MinConns < 0 {
+		addf("database.min_conns: must not be negative, got %d", c.Database.MinConns)
+	}
+	if c.Database.MinConns > c.Database.MaxConns {
+		addf("database.min_conns: %d exceeds database.max_conns %d", c.Database.MinConns, c.Database.MaxConns)
+	}
+	if c.Database.MaxConnLifetime < 0 {
+		addf("database.max_conn_lifetime: must not be negative")
+	}
+	if c.Database.ConnectTimeout < 0 {
+		addf("database.connect_timeout: must not be negative")
+	}
+
+	if _, _, err := net.SplitHostPort(c.HTTP.Addr); err != nil {
+		addf("http.addr: %v", err)
+	}
+	timeouts := []struct {
+		name  string
+		value Duration
+	}{
+		{"http.read_timeout", c.HTTP.ReadTimeout},
+		{"http.read_header_timeout", c.HTTP.ReadHeaderTimeout},
+		{"http.write_timeout", c.HTTP.WriteTimeout},
+		{"http.idle_timeout", c.HTTP.IdleTimeout},
+		{"http.request_timeout", c.HTTP.RequestTimeout},
+		{"http.shutdown_delay", c.HTTP.ShutdownDelay},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			addf("%s: must not be negative", timeout.name)
+		}
+	}
+	if c.HTTP.ReadTimeout > 0 && c.HTTP.ReadHeaderTimeout > c.HTTP.ReadTimeout {
+		addf("http.read_header_timeout: %s exceeds http.read_timeout %s", c.HTTP.ReadHeaderTimeout, c.HTTP.ReadTimeout)
+	}
+	if c.HTTP.DrainTimeout <= 0 {
+		addf("http.drain_timeout: must be positive")
+	}
+
+	if c.Pagination.DefaultLimit < 1 {
+		addf("pagination.default_limit: must be at least 1, got %d", c.Pagination.DefaultLimit)
+	}
+	if c.Pagination.MaxLimit < c.Pagination.DefaultLimit {
+		addf("pagination.max_limit: %d is below pagination.default_limit %d", c.Pagination.MaxLimit, c.Pagination.DefaultLimit)
+	}
+	if c.Pagination.MaxPageSize < c.Pagination.MaxLimit {
+		addf("pagination.max_page_size: %d is below pagination.max_limit %d", c.Pagination.MaxPageSize, c.Pagination.MaxLimit)
+	}
+
+	if secret := c.Pagination.CursorSecret; secret != "" && len(secret) < 16 {
+		addf("pagination.cursor_secret: must be at least 16 bytes, got %d", len(secret))
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		addf("health.check_timeout: must be positive")
+	}
+	if c.Health.MaxPoolSaturation <= 0 || c.Health.MaxPoolSaturation > 1 {
+		addf("health.max_pool_saturation: must be in (0, 1], got %g", c.Health.MaxPoolSaturation)
+	}
+
+	if c.Compression.MinSize < 0 {
+		addf("compression.min_size: must not be negative, got %d", c.Compression.MinSize)
+	}
+	if c.Compression.GzipLevel < 1 || c.Compression.GzipLevel > 9 {
+		addf("compression.gzip_level: must be between 1 and 9, got %d", c.Compression.GzipLevel)
+	}
+	if c.Compression.BrotliQuality < 0 || c.Compression.BrotliQuality > 11 {
+		addf("compression.brotli_quality: must be between 0 and 11, got %d", c.Compression.BrotliQuality)
+	}
+	if c.Compression.Enabled && len(c.Compression.ContentTypes) == 0 {
+		addf("compression.content_types: must list at least one media type when compression is enabled")
+	}
+
+	if c.RateLimit.EvictionInterval <= 0 {
+		addf("rate_limit.eviction_interval: must be positive")
+	}
+	routes := make([]string, 0, len(c.RateLimit.Policies))
+	for route := range c.RateLimit.Policies {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		policy := c.RateLimit.Policies[route]
+		if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+			addf("rate_limit.policies: %q must look like \"POST /api/forum/create\"", route)
+		}
+		if policy.Rate <= 0 {
+			addf("rate_limit.policies[%q].rate: must be positive, got %g", route, policy.Rate)
+		}
+		if policy.Burst < 1 {
+			addf("rate_limit.policies[%q].burst: must be at least 1, got %d", route, policy.Burst)
+		}
+	}
+
+	if c.GraphQL.MaxDepth < 1 {
+		addf("graphql.max_depth: must be at least 1, got %d", c.GraphQL.MaxDepth)
+	}
+	if c.GraphQL.MaxComplexity < 1 {
+		addf("graphql.max_complexity: must be at least 1, got %d", c.GraphQL.MaxComplexity)
+	}
+	if c.GraphQL.MaxQueryLength < 1 {
+		addf("graphql.max_query_length: must be at least 1, got %d", c.GraphQL.MaxQueryLength)
+	}
+
+	if c.GRPC.Enabled {
+		if _, _, err := net.SplitHostPort(c.GRPC.Addr); err != nil {
+			addf("grpc.addr: %v", err)
+		} else if c.GRPC.Addr == c.HTTP.Addr {
+			addf("grpc.addr: must differ from http.addr %s", c.HTTP.Addr)
+		}
+	}
+
+	if c.Events.History < 1 {
+		addf("events.history: must be at least 1, got %d", c.Events.History)
+	}
+	if c.Events.Buffer < 1 {
+		addf("events.buffer: must be at least 1, got %d", c.Events.Buffer)
+	}
+	if c.Events.KeepAlive <= 0 {
+		addf("events.keep_alive: must be positive")
+	}
+	if c.Events.Retention <= 0 {
+		addf("events.retention: must be positive")
+	}
+
+	if c.Webhooks.Workers < 1 {
+		addf("webhooks.workers: must be at least 1, got %d", c.Webhooks.Workers)
+	}
+	if c.Webhooks.PollInterval <= 0 {
+		addf("webhooks.poll_interval: must be positive")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		addf("webhooks.timeout: must be positive")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		addf("webhooks.max_attempts: must be at least 1, got %d", c.Webhooks.MaxAttempts)
+	}
+	if c.Webhooks.BackoffBase <= 0 {
+		addf("webhooks.backoff_base: must be positive")
+	} else if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+		addf("webhooks.backoff_max: must be at least webhooks.backoff_base")
+	}
+	if c.Webhooks.Retention <= 0 {
+		addf("webhooks.retention: must be positive")
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		addf("idempotency.ttl: must be positive")
+	}
+	if c.Idempotency.Lease <= 0 {
+		addf("idempotency.lease: must be positive")
+	} else if c.Idempotency.Lease > c.Idempotency.TTL {
+		addf("idempotency.lease: must be at most idempotency.ttl")
+	}
+	if c.Idempotency.MaxBodySize < 1 {
+		addf("idempotency.max_body_size: must be at least 1, got %d", c.Idempotency.MaxBodySize)
+	}
+	if secret := c.Idempotency.Secret; secret != "" && len(secret) < 16 {
+		addf("idempotency.secret: must be at least 16 bytes, got %d", len(secret))
+	}
+
+	if c.Auth.Enabled && len(c.Auth.Secret) < minAuthSecretLength {
+		addf("auth.secret: must be at least %d characters when auth is enabled", minAuthSecretLength)
+	}
+	if c.Auth.AccessTTL <= 0 {
+		addf("auth.access_ttl: must be positive")
+	}
+	if c.Auth.RefreshTTL < c.Auth.AccessTTL {
+		addf("auth.refresh_ttl: must be at least auth.access_ttl")
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		addf("auth.bcrypt_cost: must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, c.Auth.BcryptCost)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
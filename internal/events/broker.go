@@ -0,0 +1,288 @@
+// Package events fans thread changes out to the live update streams.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types. Reset is not a change: it is sent first to a subscriber
+// resuming from an event the broker no longer has, which has to reload the
+// thread because it may have missed anything up to the reset's id.
+const (
+	PostCreated   = "post-created"
+	PostEdited    = "post-edited"
+	VoteChanged   = "vote-changed"
+	ThreadUpdated = "thread-updated"
+	Reset         = "reset"
+)
+
+var (
+	// ErrLagged ends a subscription whose buffer filled up. The subscriber
+	// is expected to reconnect and resume from the last event it handled.
+	ErrLagged = errors.New("events: subscriber fell behind")
+	// ErrClosed ends the subscriptions of a broker that is shutting down.
+	ErrClosed = errors.New("events: broker closed")
+)
+
+// Event is one change of a thread. Data is the JSON of the changed post or
+// thread.
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte
+}
+
+var emptyData = []byte("{}")
+
+// Broker keeps a topic per watched thread: the subscribers and the last
+// history events, so a subscriber that reconnects can resume where it left
+// off. Topics are created by the first subscriber, so changes of threads
+// nobody watches cost nothing, and Evict drops them once they have had no
+// subscriber for longer than retention.
+//
+// Event ids increase across all topics and start at the broker's creation
+// time in microseconds, so ids handed out before a restart are older than
+// anything a new broker has and resuming from them yields a Reset.
+type Broker struct {
+	history   int
+	buffer    int
+	retention time.Duration
+	seq       atomic.Uint64
+
+	mu     sync.RWMutex
+	topics map[int]*topic
+	closed bool
+}
+
+type topic struct {
+	mu sync.Mutex
+	// ring holds the newest events, oldest at start once it is full.
+	ring  []Event
+	start int
+	// floor is the id after which the topic has every event; a subscriber
+	// resuming from an earlier id may have missed some.
+	floor uint64
+	subs  map[*Subscription]struct{}
+	// idle is when the last subscriber left.
+	idle time.Time
+}
+
+func NewBroker(history, buffer int, retention time.Duration) *Broker {
+	b := &Broker{
+		history:   history,
+		buffer:    buffer,
+		retention: retention,
+		topics:    make(map[int]*topic),
+	}
+	b.seq.Store(uint64(time.Now().UnixMicro()))
+	return b
+}
+
+// Watched reports whether thread has a topic, that is whether Publish would
+// keep its events. A nil Broker watches nothing.
+func (b *Broker) Watched(thread int) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.topics[thread]
+	return ok
+}
+
+// Publish sends v as an event of thread to its subscribers. It never
+// blocks: a subscriber whose buffer is full is dropped with ErrLagged. A
+// nil Broker drops every event.
+func (b *Broker) Publish(thread int, eventType string, v interface{}) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	t, ok := b.topics[thread]
+	if !ok || b.closed {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("encode %s event of thread %d: %v", eventType, thread, err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Taking the id under the topic lock keeps the ids of a topic in the
+	// order its subscribers receive them.
+	event := Event{ID: b.seq.Add(1), Type: eventType, Data: data}
+	t.append(event, b.history)
+	for sub := range t.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			t.drop(sub, ErrLagged)
+			metrics.EventSubscribersLagged.Inc()
+		}
+	}
+}
+
+// Subscribe starts receiving the events of thread. A non-zero lastID
+// resumes after that event: the retained events following it are delivered
+// first, or a Reset if some of them are gone.
+func (b *Broker) Subscribe(thread int, lastID uint64) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	t, ok := b.topics[thread]
+	if !ok {
+		t = &topic{floor: b.seq.Load(), subs: make(map[*Subscription]struct{})}
+		b.topics[thread] = t
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var backlog []Event
+	if lastID != 0 {
+		if lastID < t.floor || lastID > b.seq.Load() {
+			backlog = []Event{{ID: t.latest(), Type: Reset, Data: emptyData}}
+		} else {
+			backlog = t.since(lastID)
+		}
+	}
+
+	sub := &Subscription{topic: t, ch: make(chan Event, len(backlog)+b.buffer)}
+	for _, event := range backlog {
+		sub.ch <- event
+	}
+	t.subs[sub] = struct{}{}
+	metrics.EventSubscribers.Inc()
+	return sub, nil
+}
+
+// Evict drops the topics that have had no subscriber for longer than the
+// retention period.
+func (b *Broker) Evict() int {
+	deadline := time.Now().Add(-b.retention)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	evicted := 0
+	for thread, t := range b.topics {
+		t.mu.Lock()
+		if len(t.subs) == 0 && t.idle.Before(deadline) {
+			delete(b.topics, thread)
+			evicted++
+		}
+		t.mu.Unlock()
+	}
+	return evicted
+}
+
+// Run evicts idle topics every interval until ctx is done.
+func (b *Broker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Evict()
+		}
+	}
+}
+
+// Close ends every subscription with ErrClosed and refuses new ones, so
+// the streams finish while the server drains.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, t := range b.topics {
+		t.mu.Lock()
+		for sub := range t.subs {
+			t.drop(sub, ErrClosed)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// append adds event to the ring, moving the floor past the event it pushes
+// out. It must be called with t.mu held.
+func (t *topic) append(event Event, history int) {
+	if len(t.ring) < history {
+		t.ring = append(t.ring, event)
+		return
+	}
+	t.floor = t.ring[t.start].ID
+	t.ring[t.start] = event
+	t.start = (t.start + 1) % len(t.ring)
+}
+
+// since returns the retained events after id, oldest first.
+func (t *topic) since(id uint64) []Event {
+	var events []Event
+	for i := range t.ring {
+		if event := t.ring[(t.start+i)%len(t.ring)]; event.ID > id {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// latest is the id of the newest event, or the floor if there is none.
+func (t *topic) latest() uint64 {
+	if len(t.ring) == 0 {
+		return t.floor
+	}
+	return t.ring[(t.start+len(t.ring)-1)%len(t.ring)].ID
+}
+
+// drop ends sub with err. It must be called with t.mu held.
+func (t *topic) drop(sub *Subscription, err error) {
+	delete(t.subs, sub)
+	sub.err = err
+	close(sub.ch)
+	if len(t.subs) == 0 {
+		t.idle = time.Now()
+	}
+	metrics.EventSubscribers.Dec()
+}
+
+// Subscription receives the events of one thread until it is closed or
+// the broker ends it.
+type Subscription struct {
+	topic *topic
+	ch    chan Event
+	err   error
+}
+
+// Events is closed when the subscription ends; Err then tells why.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Err is ErrLagged or ErrClosed once Events is closed by the broker, nil
+// otherwise.
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Close unsubscribes. It is safe to call after the broker ended the
+// subscription.
+func (s *Subscription) Close() {
+	s.topic.mu.Lock()
+	defer s.topic.mu.Unlock()
+	if _, ok := s.topic.subs[s]; ok {
+		s.topic.drop(s, nil)
+	}
+}
@@ -0,0 +1,114 @@
+// Package idempotency keeps the responses to requests sent with an
+// Idempotency-Key header so retries of them can be answered without
+// repeating the write.
+package idempotency
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Header is the request header carrying the key, and ReplayedHeader marks a
+// response that was stored rather than produced by the request.
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	// storeTimeout bounds storing or releasing a key after the handler is
+	// done, which happens outside the request's deadline.
+	storeTimeout  = 5 * time.Second
+	purgeInterval = time.Hour
+)
+
+// Record is a key's request hash and, once the request has finished, its
+// response. Status is 0 while the request is in flight. Created identifies
+// the reservation: a key taken over after its lease ran out gets a new one,
+// so the request that lost it cannot store a response any more.
+type Record struct {
+	RequestHash []byte
+	Status      int
+	Header      http.Header
+	Body        []byte
+	Created     time.Time
+}
+
+// Store persists the keys. Keys are scoped, to the method and path of the
+// request, so the same key may be used on different endpoints.
+type Store interface {
+	// ReserveIdempotencyKey takes the key for a request with the given hash
+	// and reports true, unless it is held by a request younger than lease or
+	// has a response younger than ttl, in which case that record is
+	// returned.
+	ReserveIdempotencyKey(ctx context.Context, scope, key string, hash []byte, ttl, lease time.Duration) (Record, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, created time.Time, response Record) error
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string, created time.Time) error
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Keys struct {
+	store  Store
+	cfg    config.Idempotency
+	secret []byte
+}
+
+func NewKeys(store Store, cfg config.Idempotency) *Keys {
+	secret := []byte(cfg.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	return &Keys{store: store, cfg: cfg, secret: secret}
+}
+
+// Reserve takes key for a request with body. When it is not taken, the
+// returned record is the one holding it.
+func (k *Keys) Reserve(ctx context.Context, scope, key string, body []byte) (Record, bool, error) {
+	return k.store.ReserveIdempotencyKey(ctx, scope, key, k.Hash(body), k.cfg.TTL.Std(), k.cfg.Lease.Std())
+}
+
+// Hash is the request hash of body that a retry has to match. It is keyed
+// so a stored hash cannot be used to guess a password in a login body.
+func (k *Keys) Hash(body []byte) []byte {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Complete stores the response of the request holding the reservation.
+func (k *Keys) Complete(scope, key string, reservation Record, response Record) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	return k.store.CompleteIdempotencyKey(ctx, scope, key, reservation.Created, response)
+}
+
+// Release gives up a reservation so the next retry runs the request again.
+func (k *Keys) Release(scope, key string, reservation Record) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	return k.store.ReleaseIdempotencyKey(ctx, scope, key, reservation.Created)
+}
+
+// Run deletes the expired keys every hour until ctx is done. Expired keys
+// are reusable before that; this only keeps the table small.
+func (k *Keys) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := k.store.PurgeIdempotencyKeys(ctx, time.Now().Add(-k.cfg.TTL.Std())); err != nil && ctx.Err() == nil {
+				log.Printf("purge idempotency keys: %v", err)
+			}
+		}
+	}
+}
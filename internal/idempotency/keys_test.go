@@ -0,0 +1,48 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"testing"
+)
+
+func TestHashIsKeyed(t *testing.T) {
+	body := []byte(`{"nickname":"j.sparrow","password":"black pearl"}`)
+	cfg := config.Default().Idempotency
+	cfg.Secret = "a secret of sixteen bytes or more"
+	keys := NewKeys(nil, cfg)
+
+	hash := keys.Hash(body)
+	if !bytes.Equal(hash, keys.Hash(body)) {
+		t.Error("the hash of the same body changed")
+	}
+	if !bytes.Equal(hash, NewKeys(nil, cfg).Hash(body)) {
+		t.Error("instances sharing the secret hash differently")
+	}
+	if plain := sha256.Sum256(body); bytes.Equal(hash, plain[:]) {
+		t.Error("the hash is the plain SHA-256 of the body")
+	}
+	if bytes.Equal(hash, keys.Hash([]byte(`{"nickname":"j.sparrow","password":"black pearl!"}`))) {
+		t.Error("different bodies have the same hash")
+	}
+
+	other := cfg
+	other.Secret = "another secret of sixteen bytes"
+	if bytes.Equal(hash, NewKeys(nil, other).Hash(body)) {
+		t.Error("the hash does not depend on the secret")
+	}
+}
+
+func TestHashRandomSecret(t *testing.T) {
+	body := []byte(`{"nickname":"j.sparrow","password":"black pearl"}`)
+	cfg := config.Default().Idempotency
+	cfg.Secret = ""
+	first, second := NewKeys(nil, cfg), NewKeys(nil, cfg)
+	if bytes.Equal(first.Hash(body), second.Hash(body)) {
+		t.Error("two processes without a secret hash alike")
+	}
+	if !bytes.Equal(first.Hash(body), first.Hash(body)) {
+		t.Error("the hash of the same body changed")
+	}
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+const namespace = "forum"
+
+const (
+	EntityUser   = "user"
+	EntityForum  = "forum"
+	EntityThread = "thread"
+	EntityPost   = "post"
+	EntityVote   = "vote"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by mux route template.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of ForumRepository operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 18),
+	}, []string{"query"})
+
+	EntitiesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "entities_created_total",
+		Help:      "Users, forums, threads, posts and votes created.",
+	}, []string{"entity"})
+
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected with 429 by mux route template.",
+	}, []string{"method", "route"})
+
+	EventSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "subscribers",
+		Help:      "Open thread event streams.",
+	})
+
+	EventSubscribersLagged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "events",
+		Name:      "subscribers_lagged_total",
+		Help:      "Thread event streams dropped for falling behind.",
+	})
+
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "attempts_total",
+		Help:      "Webhook delivery attempts by outcome: delivered, retry or dead.",
+	}, []string{"result"})
+)
+
+// ObserveQuery starts timing a repository operation; call the returned
+// function when it completes.
+func ObserveQuery(query string) func() {
+	start := time.Now()
+	return func() {
+		DBQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	}
+}
+
+func Created(entity string, count int) {
+	EntitiesCreated.WithLabelValues(entity).Add(float64(count))
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolAcquiredDesc = poolDesc("acquired_conns", "Connections currently in use.")
+	poolIdleDesc     = poolDesc("idle_conns", "Idle connections.")
+	poolTotalDesc    = poolDesc("total_conns", "Open connections.")
+	poolMaxDesc      = poolDesc("max_conns", "Maximum size of the pool.")
+	poolAcquiresDesc = poolDesc("acquires_total", "Successful connection acquisitions.")
+	poolWaitsDesc    = poolDesc("wait_count_total", "Acquisitions that had to wait for a connection.")
+	poolWaitTimeDesc = poolDesc("acquire_duration_seconds_total", "Total time spent acquiring connections.")
+	poolCanceledDesc = poolDesc("canceled_acquires_total", "Acquisitions cancelled by their context.")
+)
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+// PoolCollector exports pgxpool statistics at scrape time.
+type PoolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{pool: pool}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredDesc
+	ch <- poolIdleDesc
+	ch <- poolTotalDesc
+	ch <- poolMaxDesc
+	ch <- poolAcquiresDesc
+	ch <- poolWaitsDesc
+	ch <- poolWaitTimeDesc
+	ch <- poolCanceledDesc
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWaitsDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolWaitTimeDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolCanceledDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
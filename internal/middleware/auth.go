@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+	"strings"
+)
+
+// Authenticate resolves the bearer token of a request into the user it
+// acts as, which the usecase finds in the request context. Requests without
+// an Authorization header pass anonymously, so reads stay open and the
+// usecase decides what needs a user; a token that does not verify gets 401
+// rather than being ignored.
+func Authenticate(authenticate func(ctx context.Context, token string) (auth.Principal, error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			scheme, token, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				utils.ErrorResponse(w, r, fmt.Errorf("%w: Authorization must be a Bearer token", models.ErrorUnauthorized), "", "")
+				return
+			}
+			principal, err := authenticate(r.Context(), strings.TrimSpace(token))
+			if err != nil {
+				utils.ErrorResponse(w, r, err, "", "")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireScope rejects requests whose credentials lack scope with 403.
+// Logins hold every scope, so only API keys are ever rejected; anonymous
+// requests pass like they do Authenticate.
+func RequireScope(scope auth.Scope) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal, ok := auth.FromContext(r.Context()); ok && !principal.Can(scope) {
+				utils.ErrorResponse(w, r, fmt.Errorf("%w: API key lacks the %s scope", models.ErrorForbidden, scope), "", "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
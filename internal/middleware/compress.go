@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+)
+
+// encoder is the part of gzip.Writer and brotli.Writer the middleware uses.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Compress encodes responses with brotli or gzip, whichever the client
+// prefers in Accept-Encoding; brotli wins a tie. The body is held back until
+// it reaches MinSize so small responses are sent uncompressed, and encoders
+// are pooled because allocating one costs far more than a typical response.
+func Compress(cfg config.Compression) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		contentTypes := make(map[string]bool, len(cfg.ContentTypes))
+		for _, contentType := range cfg.ContentTypes {
+			contentTypes[strings.ToLower(contentType)] = true
+		}
+		pools := map[string]*sync.Pool{
+			encodingGzip: {New: func() interface{} {
+				// The level has been validated with the config.
+				w, _ := gzip.NewWriterLevel(io.Discard, cfg.GzipLevel)
+				return w
+			}},
+			encodingBrotli: {New: func() interface{} {
+				return brotli.NewWriterLevel(io.Discard, cfg.BrotliQuality)
+			}},
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				minSize:        cfg.MinSize,
+				contentTypes:   contentTypes,
+				status:         http.StatusOK,
+			}
+			// A handler that panics, http.ErrAbortHandler included, leaves a
+			// response that must not be completed, but the encoder still
+			// goes back to its pool.
+			completed := false
+			defer func() {
+				if completed {
+					cw.Close()
+				} else {
+					cw.release()
+				}
+			}()
+			next.ServeHTTP(cw, r)
+			completed = true
+		})
+	}
+}
+
+// negotiateEncoding returns the supported coding with the highest q-value in
+// an Accept-Encoding header, or "" when identity should be used.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != encodingGzip && coding != encodingBrotli && coding != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if coding == "*" {
+			coding = encodingBrotli
+		}
+		if q > bestQ || (q == bestQ && coding == encodingBrotli) {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is worth compressing, then either streams it through a pooled
+// encoder or passes it through untouched.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding     string
+	pool         *sync.Pool
+	minSize      int
+	contentTypes map[string]bool
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         bytes.Buffer
+	encoder     encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.wroteHeader = true
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// decide settles on compressing or not and sends the headers and whatever
+// has been buffered. bigEnough is false when the handler finished or flushed
+// before the body reached the minimum size.
+func (w *compressWriter) decide(bigEnough bool) error {
+	w.decided = true
+	if bigEnough && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// The encoded bytes differ from the ones a strong ETag was computed
+		// for, so only a weak validator remains truthful.
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.encoder = w.pool.Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf = bytes.Buffer{}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return w.contentTypes[mediaType]
+}
+
+// Close sends a body that never reached the threshold and finishes the
+// encoded stream, returning the encoder to its pool.
+func (w *compressWriter) Close() {
+	if !w.decided {
+		if !w.wroteHeader {
+			// The handler wrote nothing at all.
+			w.status = http.StatusOK
+		}
+		_ = w.decide(false)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+	}
+	w.release()
+}
+
+// release returns the encoder to its pool without finishing the stream.
+func (w *compressWriter) release() {
+	if w.encoder != nil {
+		w.encoder.Reset(io.Discard)
+		w.pool.Put(w.encoder)
+		w.encoder = nil
+	}
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush commits to a decision early: a handler that flushes is streaming, so
+// waiting for MinSize would only delay it.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		_ = w.decide(true)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	w.decided = true
+	return hijacker.Hijack()
+}
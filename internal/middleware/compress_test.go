@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postsBody is the JSON of a thread page with n posts.
+func postsBody(n int) []byte {
+	created := time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC)
+	posts := make(models.PostsList, 0, n)
+	for i := 1; i <= n; i++ {
+		posts = append(posts, models.Post{
+			ID:      i,
+			Parent:  i / 2,
+			Author:  fmt.Sprintf("user.%d", i%37),
+			Message: fmt.Sprintf("Post number %d, replying to %d. %s", i, i/2, strings.Repeat("Lorem ipsum dolor sit amet. ", i%5+1)),
+			Forum:   "pirate-stories",
+			Thread:  42,
+			Created: created.Add(time.Duration(i) * time.Second),
+		})
+	}
+	body, err := json.Marshal(posts)
+	if err != nil {
+		panic(err)
+	}
+	return body
+}
+
+func serveBody(body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	body := postsBody(100)
+	handler := Compress(config.Default().Compression)(serveBody(body))
+
+	cases := []struct {
+		acceptEncoding string
+		want           string
+		decode         func(io.Reader) (io.Reader, error)
+	}{
+		{"", "", nil},
+		{"identity", "", nil},
+		{"gzip", encodingGzip, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"gzip, br", encodingBrotli, func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+		{"gzip;q=1, br;q=0.5", encodingGzip, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"*", encodingBrotli, func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.acceptEncoding, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/thread/42/posts", nil)
+			r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Encoding"); got != tc.want {
+				t.Fatalf("Content-Encoding %q, want %q", got, tc.want)
+			}
+			var reader io.Reader = w.Body
+			if tc.decode != nil {
+				var err error
+				if reader, err = tc.decode(reader); err != nil {
+					t.Fatal(err)
+				}
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, body) {
+				t.Error("decoded body differs from the one written")
+			}
+		})
+	}
+}
+
+func TestCompressSmallBody(t *testing.T) {
+	handler := Compress(config.Default().Compression)(serveBody([]byte(`{"message":"short"}`)))
+	r := httptest.NewRequest(http.MethodGet, "/api/service/status", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding %q on a body below the minimum size", got)
+	}
+	if got := w.Body.String(); got != `{"message":"short"}` {
+		t.Errorf("body %q", got)
+	}
+}
+
+func TestCompressAbortedHandler(t *testing.T) {
+	body := postsBody(100)
+	cfg := config.Default().Compression
+
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"before writing", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}},
+		{"below the minimum size", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body[:cfg.MinSize/2])
+			panic(http.ErrAbortHandler)
+		}},
+		{"while streaming", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(body)
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Compress(cfg)(tc.handler)
+			r := httptest.NewRequest(http.MethodGet, "/api/thread/42/posts", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != http.ErrAbortHandler {
+						t.Fatalf("recovered %v, want http.ErrAbortHandler", recovered)
+					}
+				}()
+				handler.ServeHTTP(w, r)
+			}()
+
+			if w.Body.Len() == 0 {
+				return
+			}
+			// Whatever went out must not look like a complete response.
+			reader, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = io.ReadAll(reader); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("reading the aborted stream: %v, want io.ErrUnexpectedEOF", err)
+			}
+		})
+	}
+}
+
+func benchmarkCompress(b *testing.B, acceptEncoding string) {
+	body := postsBody(2000)
+	handler := Compress(config.Default().Compression)(serveBody(body))
+	r := httptest.NewRequest(http.MethodGet, "/api/thread/42/posts", nil)
+	if acceptEncoding != "" {
+		r.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	var sent int
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		sent = w.Body.Len()
+	}
+	b.ReportMetric(float64(sent), "bytes/op")
+}
+
+func BenchmarkCompressIdentity(b *testing.B) {
+	benchmarkCompress(b, "")
+}
+
+func BenchmarkCompressGzip(b *testing.B) {
+	benchmarkCompress(b, encodingGzip)
+}
+
+func BenchmarkCompressBrotli(b *testing.B) {
+	benchmarkCompress(b, encodingBrotli)
+}
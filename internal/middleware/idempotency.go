@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/idempotency"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored with a key and replayed
+// with its response.
+var replayedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Location"}
+
+// Idempotency makes POST and DELETE requests with an Idempotency-Key header
+// safe to retry. The first request with a key runs and its response is
+// stored; a retry with the same body gets that response again, marked with
+// Idempotent-Replayed, while a retry with another body gets 422 and one
+// arriving before the first has finished gets 409. Keys are scoped to the
+// method, the path and the authenticated user. Server errors and no-store
+// responses are not stored, so the request runs again when retried. The
+// body is read whole to hash it, so one longer than maxBodySize gets 413.
+// Requests without the header are not affected.
+func Idempotency(keys *idempotency.Keys, maxBodySize int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotency.Header)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodDelete) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				err := fmt.Errorf("%w: %s must be 1 to %d printable ASCII characters", models.ErrorBadRequest, idempotency.Header, maxIdempotencyKeyLength)
+				utils.ErrorResponse(w, r, err, "", "")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBodySize)))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				err = fmt.Errorf("%w: Request body sent with %s must be at most %d bytes", models.ErrorPayloadTooLarge, idempotency.Header, maxBodySize)
+				utils.ErrorResponse(w, r, err, "", "")
+				return
+			} else if err != nil {
+				utils.ErrorResponse(w, r, utils.BadRequest(err), "", "")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := r.Method + " " + r.URL.Path
+			if principal, ok := auth.FromContext(r.Context()); ok {
+				// Two users cannot see each other's responses by picking
+				// the same key.
+				scope = principal.Nickname + " " + scope
+			}
+			record, reserved, err := keys.Reserve(r.Context(), scope, key, body)
+			if err != nil {
+				if errors.Is(err, models.ErrorConflict) {
+					w.Header().Set("Retry-After", "1")
+				}
+				utils.ErrorResponse(w, r, err, utils.ResourceIdempotencyKey, key)
+				return
+			}
+			if !reserved {
+				replay(w, r, keys, key, body, record)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			stored := false
+			defer func() {
+				// Also reached when the handler panics; the key must not stay
+				// held until its lease runs out.
+				if !stored {
+					if err := keys.Release(scope, key, record); err != nil {
+						log.Printf("release idempotency key %q: %v", key, err)
+					}
+				}
+			}()
+			next.ServeHTTP(rec, r)
+
+			// Responses carrying credentials are marked no-store and must not
+			// end up in the database either.
+			if rec.status >= http.StatusInternalServerError || strings.Contains(w.Header().Get("Cache-Control"), "no-store") {
+				return
+			}
+			response := idempotency.Record{Status: rec.status, Header: make(http.Header), Body: rec.body.Bytes()}
+			for _, name := range replayedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					response.Header[http.CanonicalHeaderKey(name)] = values
+				}
+			}
+			if err = keys.Complete(scope, key, record, response); err != nil {
+				log.Printf("store idempotency key %q: %v", key, err)
+				return
+			}
+			stored = true
+		})
+	}
+}
+
+// replay answers a retry from the record holding its key.
+func replay(w http.ResponseWriter, r *http.Request, keys *idempotency.Keys, key string, body []byte, record idempotency.Record) {
+	hash := keys.Hash(body)
+	if subtle.ConstantTimeCompare(hash, record.RequestHash) != 1 {
+		utils.ErrorResponse(w, r, models.ErrorUnprocessableEntity, utils.ResourceIdempotencyKey, key)
+		return
+	}
+	if record.Status == 0 {
+		w.Header().Set("Retry-After", "1")
+		utils.ErrorResponse(w, r, models.ErrorConflict, utils.ResourceIdempotencyKey, key)
+		return
+	}
+	header := w.Header()
+	for name, values := range record.Header {
+		header[http.CanonicalHeaderKey(name)] = values
+	}
+	header.Set(idempotency.ReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	_, _ = w.Write(record.Body)
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// recorder keeps a copy of the response it passes through.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
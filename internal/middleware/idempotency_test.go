@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/idempotency"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memKeys is an idempotency.Store in memory that ignores expiry.
+type memKeys struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+}
+
+func newMemKeys() *memKeys {
+	return &memKeys{records: make(map[string]idempotency.Record)}
+}
+
+func (s *memKeys) ReserveIdempotencyKey(ctx context.Context, scope, key string, hash []byte, ttl, lease time.Duration) (idempotency.Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[scope+"\x00"+key]; ok {
+		return record, false, nil
+	}
+	record := idempotency.Record{RequestHash: hash, Created: time.Now()}
+	s.records[scope+"\x00"+key] = record
+	return record, true, nil
+}
+
+func (s *memKeys) CompleteIdempotencyKey(ctx context.Context, scope, key string, created time.Time, response idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[scope+"\x00"+key]
+	response.RequestHash, response.Created = record.RequestHash, record.Created
+	s.records[scope+"\x00"+key] = response
+	return nil
+}
+
+func (s *memKeys) ReleaseIdempotencyKey(ctx context.Context, scope, key string, created time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, scope+"\x00"+key)
+	return nil
+}
+
+func (s *memKeys) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *memKeys) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// idempotentHandler counts the requests reaching it and echoes their body
+// length.
+func idempotentHandler(store *memKeys, maxBodySize int, calls *int) http.Handler {
+	keys := idempotency.NewKeys(store, config.Default().Idempotency)
+	return Idempotency(keys, maxBodySize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]int{"length": len(body)})
+	}))
+}
+
+func post(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/thread/42/create", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		r.Header.Set(idempotency.Header, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	const limit = 64
+	store := newMemKeys()
+	calls := 0
+	handler := idempotentHandler(store, limit, &calls)
+
+	w := post(handler, "too-large", strings.Repeat("x", limit+1))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got %d, want 413", w.Code)
+	}
+	var body models.Error
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != models.CodePayloadTooLarge || !strings.Contains(body.Message, "64 bytes") {
+		t.Errorf("error body %+v", body)
+	}
+	if calls != 0 || store.len() != 0 {
+		t.Errorf("an oversized request reached the handler %d times and left %d keys", calls, store.len())
+	}
+
+	if w = post(handler, "at-limit", strings.Repeat("x", limit)); w.Code != http.StatusCreated {
+		t.Fatalf("body at the limit got %d", w.Code)
+	}
+	if w = post(handler, "at-limit", strings.Repeat("x", limit)); w.Code != http.StatusCreated || w.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Fatalf("retry got %d, replayed %q", w.Code, w.Header().Get(idempotency.ReplayedHeader))
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+
+	// Without a key the body is left to the handler.
+	if w = post(handler, "", strings.Repeat("x", 10*limit)); w.Code != http.StatusCreated {
+		t.Fatalf("request without a key got %d", w.Code)
+	}
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	store := newMemKeys()
+	calls := 0
+	handler := idempotentHandler(store, 1<<10, &calls)
+
+	first := post(handler, "k1", `[{"author":"j.sparrow","message":"Yo ho"}]`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("got %d", first.Code)
+	}
+	retry := post(handler, "k1", `[{"author":"j.sparrow","message":"Yo ho"}]`)
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry got %d %q, want %d %q", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if other := post(handler, "k1", `[{"author":"j.sparrow","message":"Yo ho ho"}]`); other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reuse with another body got %d, want 422", other.Code)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once", calls)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AccessLog writes one logfmt line per request once the response is done.
+// The line is written from a defer so requests whose handler panics, such
+// as those aborted with http.ErrAbortHandler, are logged too: they are
+// marked aborted=true, with status=0 when no response was sent.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := wrap(w)
+		completed := false
+		defer func() {
+			status, aborted := rw.status, ""
+			if !completed {
+				aborted = " aborted=true"
+				if !rw.wroteHeader {
+					status = 0
+				}
+			}
+			log.Printf("access request_id=%s method=%s path=%s status=%d bytes=%d duration_ms=%.3f remote=%s user_agent=%s%s",
+				RequestIDFromContext(r.Context()), r.Method, strconv.Quote(r.URL.RequestURI()), status, rw.bytes,
+				float64(time.Since(start).Microseconds())/1000, r.RemoteAddr, strconv.Quote(r.UserAgent()), aborted)
+		}()
+		next.ServeHTTP(rw, r)
+		completed = true
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// accessLog serves one request through AccessLog and Recover and returns
+// the access line together with what the handler panicked with.
+func accessLog(t *testing.T, h http.HandlerFunc) (line string, recovered interface{}) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	func() {
+		defer func() { recovered = recover() }()
+		Chain(h, AccessLog, Recover).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/thread/42/posts", nil))
+	}()
+	for _, l := range strings.Split(out.String(), "\n") {
+		if strings.Contains(l, "access ") {
+			return l, recovered
+		}
+	}
+	t.Fatalf("no access line in %q", out.String())
+	return "", recovered
+}
+
+func TestAccessLog(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    []string
+		absent  []string
+		aborted bool
+	}{
+		{"completed", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("[]"))
+		}, []string{"status=201", "bytes=2", `path="/api/thread/42/posts"`}, []string{"aborted"}, false},
+		{"panic", func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}, []string{"status=500"}, []string{"aborted"}, false},
+		{"aborted before writing", func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}, []string{"status=0", "bytes=0", "aborted=true"}, nil, true},
+		{"aborted while streaming", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("[{"))
+			panic(http.ErrAbortHandler)
+		}, []string{"status=200", "bytes=2", "aborted=true"}, nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			line, recovered := accessLog(t, tc.handler)
+			if tc.aborted != (recovered == http.ErrAbortHandler) {
+				t.Errorf("recovered %v", recovered)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(line, want) {
+					t.Errorf("%q lacks %s", line, want)
+				}
+			}
+			for _, absent := range tc.absent {
+				if strings.Contains(line, absent) {
+					t.Errorf("%q has %s", line, absent)
+				}
+			}
+			if !strings.Contains(line, "duration_ms=") {
+				t.Errorf("%q lacks the duration", line)
+			}
+		})
+	}
+}
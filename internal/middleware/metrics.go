@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics records request latency labelled with the route template, so
+// /api/user/{nickname}/profile is one series rather than one per user. It
+// must be installed with Router.Use to see the matched route.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := wrap(w)
+		next.ServeHTTP(rw, r)
+
+		route := "unmatched"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route, strconv.Itoa(rw.status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
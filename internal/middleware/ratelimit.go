@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/ratelimit"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxActorPeek bounds how much of a request body is read to find the acting
+// nickname; larger bodies are only limited by client IP.
+const maxActorPeek = 1 << 20
+
+// actorFields are the payload fields that name the acting user: nickname in
+// votes, author in threads and posts, user in forums.
+var actorFields = []string{"nickname", "author", "user"}
+
+// RateLimit rejects requests to limited routes with 429 once the bucket of
+// the client IP or of a nickname acting in the request is empty. Every
+// limited response carries RateLimit-* headers describing the tighter of
+// the two buckets. It must be installed with Router.Use to see the matched
+// route, and after Authenticate when authEnabled: the acting nickname is
+// then the authenticated one, since a nickname in an unauthenticated
+// payload would let anyone drain the bucket of another user.
+func RateLimit(limiter *ratelimit.Limiter, trustForwardedFor, authEnabled bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := ""
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					route = r.Method + " " + template
+				}
+			}
+			if !limiter.Limited(route) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keys := []string{"ip:" + clientIP(r, trustForwardedFor)}
+			if authEnabled {
+				if principal, ok := auth.FromContext(r.Context()); ok && principal.Nickname != "" {
+					keys = append(keys, "nickname:"+strings.ToLower(principal.Nickname))
+				}
+			} else {
+				for _, nickname := range actors(r) {
+					keys = append(keys, "nickname:"+nickname)
+				}
+			}
+			decision := limiter.Allow(route, keys...)
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+			if !decision.Allowed {
+				metrics.RateLimited.WithLabelValues(r.Method, route[len(r.Method)+1:]).Inc()
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+				utils.ErrorResponse(w, r, models.ErrorTooManyRequests, "", "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// actors collects the nicknames a request claims to act as, from the route
+// variables and from the payload; nothing checks the claim, so they are only
+// used with auth disabled. The body is restored for the handler. Protobuf
+// payloads have no generic form and only contribute route variables.
+func actors(r *http.Request) []string {
+	seen := make(map[string]bool)
+	var nicknames []string
+	add := func(value interface{}) {
+		nickname, ok := value.(string)
+		if !ok || nickname == "" {
+			return
+		}
+		// Nicknames are case-insensitive in the database.
+		nickname = strings.ToLower(nickname)
+		if !seen[nickname] {
+			seen[nickname] = true
+			nicknames = append(nicknames, nickname)
+		}
+	}
+	add(mux.Vars(r)["nickname"])
+
+	codec, err := utils.RequestCodec(r)
+	if err != nil || r.Body == nil || r.Body == http.NoBody {
+		return nicknames
+	}
+	peek, err := io.ReadAll(io.LimitReader(r.Body, maxActorPeek))
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(peek), r.Body), r.Body}
+	if err != nil || len(peek) == maxActorPeek {
+		return nicknames
+	}
+
+	var payload interface{}
+	if codec.Unmarshal(peek, &payload) != nil {
+		return nicknames
+	}
+	objects, ok := payload.([]interface{})
+	if !ok {
+		objects = []interface{}{payload}
+	}
+	for _, object := range objects {
+		fields, ok := object.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range actorFields {
+			add(fields[field])
+		}
+	}
+	return nicknames
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
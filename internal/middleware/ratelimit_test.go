@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const voteRoute = "POST /api/thread/{slug_or_id}/vote"
+
+// limitedRouter serves the vote route behind Authenticate, where the bearer
+// token is the nickname, and a limiter allowing burst votes per bucket.
+func limitedRouter(authEnabled bool, burst int) http.Handler {
+	limiter := ratelimit.NewLimiter(map[string]ratelimit.Policy{voteRoute: {Rate: 0.001, Burst: burst}})
+	router := mux.NewRouter()
+	router.HandleFunc("/api/thread/{slug_or_id}/vote", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodPost)
+	if authEnabled {
+		router.Use(mux.MiddlewareFunc(Authenticate(func(ctx context.Context, token string) (auth.Principal, error) {
+			return auth.Principal{Nickname: token, Scopes: auth.AllScopes}, nil
+		})))
+	}
+	router.Use(mux.MiddlewareFunc(RateLimit(limiter, true, authEnabled)))
+	return router
+}
+
+// vote sends a vote from ip, signed in as token unless it is empty, naming
+// nickname in the payload.
+func vote(handler http.Handler, ip, token, nickname string) int {
+	r := httptest.NewRequest(http.MethodPost, "/api/thread/42/vote", strings.NewReader(`{"nickname":"`+nickname+`","voice":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Forwarded-For", ip)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRateLimitIgnoresPayloadNicknamesWithAuth(t *testing.T) {
+	const burst = 3
+	handler := limitedRouter(true, burst)
+
+	// Anonymous requests from many addresses name the victim; only their
+	// addresses are limited.
+	for i := 0; i < 2*burst; i++ {
+		ip := "203.0.113." + strconv.Itoa(i+1)
+		if code := vote(handler, ip, "", "j.sparrow"); code != http.StatusOK {
+			t.Fatalf("anonymous vote %d got %d", i+1, code)
+		}
+	}
+	// So do signed-in attackers.
+	for i := 0; i < 2*burst; i++ {
+		ip := "198.51.100." + strconv.Itoa(i+1)
+		if code := vote(handler, ip, "h.barbossa."+strconv.Itoa(i+1), "j.sparrow"); code != http.StatusOK {
+			t.Fatalf("vote of another user %d got %d", i+1, code)
+		}
+	}
+
+	for i := 0; i < burst; i++ {
+		if code := vote(handler, "192.0.2.1", "j.sparrow", "j.sparrow"); code != http.StatusOK {
+			t.Fatalf("vote %d of the victim got %d", i+1, code)
+		}
+	}
+}
+
+func TestRateLimitKeysOnPrincipal(t *testing.T) {
+	const burst = 2
+	handler := limitedRouter(true, burst)
+
+	// The bucket follows the signed-in user across addresses and whatever
+	// the payload claims, case-insensitively.
+	tokens := []string{"j.sparrow", "J.Sparrow"}
+	for i := 0; i < burst; i++ {
+		if code := vote(handler, "192.0.2."+strconv.Itoa(i+1), tokens[i%len(tokens)], "w.turner"); code != http.StatusOK {
+			t.Fatalf("vote %d got %d", i+1, code)
+		}
+	}
+	if code := vote(handler, "192.0.2.100", "j.sparrow", "e.swann"); code != http.StatusTooManyRequests {
+		t.Fatalf("vote past the burst got %d, want 429", code)
+	}
+	// Users named in the payload keep their own buckets.
+	if code := vote(handler, "192.0.2.101", "w.turner", "w.turner"); code != http.StatusOK {
+		t.Fatalf("vote of w.turner got %d", code)
+	}
+}
+
+func TestRateLimitPayloadNicknamesWithoutAuth(t *testing.T) {
+	const burst = 2
+	handler := limitedRouter(false, burst)
+
+	for i := 0; i < burst; i++ {
+		if code := vote(handler, "192.0.2."+strconv.Itoa(i+1), "", "j.sparrow"); code != http.StatusOK {
+			t.Fatalf("vote %d got %d", i+1, code)
+		}
+	}
+	if code := vote(handler, "192.0.2.100", "", "J.SPARROW"); code != http.StatusTooManyRequests {
+		t.Fatalf("vote past the burst got %d, want 429", code)
+	}
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	const burst = 2
+	handler := limitedRouter(true, burst)
+
+	for i := 0; i < burst; i++ {
+		if code := vote(handler, "192.0.2.1", "", ""); code != http.StatusOK {
+			t.Fatalf("vote %d got %d", i+1, code)
+		}
+	}
+	if code := vote(handler, "192.0.2.1", "", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("vote past the burst got %d, want 429", code)
+	}
+	if code := vote(handler, "192.0.2.2", "", ""); code != http.StatusOK {
+		t.Fatalf("vote from another address got %d", code)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a handler panic into a JSON 500 instead of a dropped
+// connection. http.ErrAbortHandler is re-raised as the server expects.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := wrap(w)
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			log.Printf("panic request_id=%s method=%s path=%s: %v\n%s",
+				RequestIDFromContext(r.Context()), r.Method, r.URL.Path, recovered, debug.Stack())
+			if !rw.wroteHeader {
+				utils.ErrorResponse(rw, r, models.ErrorInternal, "", "")
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Timeout puts a deadline on the request context so database queries started
+// by the handler are cancelled once it passes. A zero timeout disables it.
+// Requests for which longLived returns true, such as event streams that stay
+// open while the client listens, get no deadline; longLived may be nil.
+func Timeout(timeout time.Duration, longLived func(*http.Request) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if longLived != nil && longLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CreateMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (version INT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now());`
+	LockMigrations        = `SELECT pg_advisory_xact_lock($1);`
+	SelectApplied         = `SELECT version, applied_at FROM schema_migrations ORDER BY version;`
+	SelectCurrentVersion  = `SELECT coalesce(max(version), 0) FROM schema_migrations;`
+	SelectIsApplied       = `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1);`
+	InsertApplied         = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+	DeleteApplied         = `DELETE FROM schema_migrations WHERE version = $1;`
+)
+
+// lockKey serialises concurrent migrators started by several instances.
+const lockKey = 7_310_420_001
+
+var ErrNoMigrations = errors.New("no migrations to roll back")
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	conn       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New reads NNNN_name.up.sql / NNNN_name.down.sql pairs from the root of
+// migrations.
+func New(conn *pgxpool.Pool, migrations fs.FS) (*Migrator, error) {
+	byVersion := make(map[int]*Migration)
+	err := fs.WalkDir(migrations, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(filePath) != ".sql" {
+			return err
+		}
+		base := strings.TrimSuffix(path.Base(filePath), ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		versionStr, name, found := strings.Cut(base, "_")
+		version, convErr := strconv.Atoi(versionStr)
+		if !found || convErr != nil || version <= 0 {
+			return fmt.Errorf("migration %s: name must look like 0001_name.up.sql", filePath)
+		}
+
+		content, err := fs.ReadFile(migrations, filePath)
+		if err != nil {
+			return err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+		switch direction {
+		case ".up":
+			m.Up = string(content)
+		case ".down":
+			m.Down = string(content)
+		default:
+			return fmt.Errorf("migration %s: direction must be up or down", filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Migrator{conn: conn}
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		result.migrations = append(result.migrations, *m)
+	}
+	sort.Slice(result.migrations, func(i, j int) bool {
+		return result.migrations[i].Version < result.migrations[j].Version
+	})
+	return result, nil
+}
+
+// Latest is the version the embedded migrations bring the schema to.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	if _, err := m.conn.Exec(ctx, CreateMigrationsTable); err != nil {
+		return 0, err
+	}
+	var version int
+	err := m.conn.QueryRow(ctx, SelectCurrentVersion).Scan(&version)
+	return version, err
+}
+
+// Up applies every pending migration, each in its own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if _, err := m.conn.Exec(ctx, CreateMigrationsTable); err != nil {
+		return nil, err
+	}
+	applied := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		done, err := m.apply(ctx, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if done {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back the most recently applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	if _, err := m.conn.Exec(ctx, CreateMigrationsTable); err != nil {
+		return Migration{}, err
+	}
+	var result Migration
+	err := m.inTx(ctx, func(tx pgx.Tx) error {
+		var version int
+		if err := tx.QueryRow(ctx, SelectCurrentVersion).Scan(&version); err != nil {
+			return err
+		}
+		if version == 0 {
+			return ErrNoMigrations
+		}
+		migration, ok := m.find(version)
+		if !ok {
+			return fmt.Errorf("applied migration %d is unknown to this binary", version)
+		}
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(ctx, DeleteApplied, version); err != nil {
+			return err
+		}
+		result = migration
+		return nil
+	})
+	return result, err
+}
+
+// Redo rolls back the latest migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	migration, err := m.Down(ctx)
+	if err != nil {
+		return migration, err
+	}
+	if _, err = m.apply(ctx, migration); err != nil {
+		return migration, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return migration, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if _, err := m.conn.Exec(ctx, CreateMigrationsTable); err != nil {
+		return nil, err
+	}
+	rows, err := m.conn.Query(ctx, SelectApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) (bool, error) {
+	applied := false
+	err := m.inTx(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx, SelectIsApplied, migration.Version).Scan(&exists)
+		if err != nil || exists {
+			return err
+		}
+		if _, err = tx.Exec(ctx, migration.Up); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(ctx, InsertApplied, migration.Version, migration.Name); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, LockMigrations, lockKey); err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
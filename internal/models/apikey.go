@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+//easyjson -all ./internal/models/apikey.go
+
+// APIKey is a long-lived credential for bots and importers. It acts as
+// Nickname, limited to Scopes.
+type APIKey struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Nickname string   `json:"nickname"`
+	Scopes   []string `json:"scopes"`
+	// Key goes in the Authorization header as a bearer token. It is only
+	// returned by the request that issues the key.
+	Key      string     `json:"key,omitempty"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
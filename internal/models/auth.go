@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+//easyjson -all ./internal/models/auth.go
+
+// Credentials is the body of a login.
+type Credentials struct {
+	Nickname string `json:"nickname"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the body of a refresh.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Session is the token pair handed out by a login or a refresh. The access
+// token goes in the Authorization header as a bearer token; the refresh
+// token gets a new pair once the access token expires and is then used up.
+type Session struct {
+	Nickname       string    `json:"nickname"`
+	AccessToken    string    `json:"access_token"`
+	TokenType      string    `json:"token_type"`
+	ExpiresIn      int       `json:"expires_in"`
+	RefreshToken   string    `json:"refresh_token"`
+	RefreshExpires time.Time `json:"refresh_expires"`
+}
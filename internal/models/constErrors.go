@@ -3,7 +3,18 @@ package models
 import "errors"
 
 var (
-	ErrorConflict = errors.New("Conflict")
-	ErrorNotFound = errors.New("NotFound")
-	ErrorInternal = errors.New("InternalError")
+	ErrorConflict   = errors.New("Conflict")
+	ErrorNotFound   = errors.New("NotFound")
+	ErrorInternal   = errors.New("InternalError")
+	ErrorBadRequest = errors.New("BadRequest")
+	ErrorForbidden  = errors.New("Forbidden")
+
+	ErrorUnauthorized = errors.New("Unauthorized")
+
+	ErrorUnsupportedMediaType = errors.New("UnsupportedMediaType")
+	ErrorPreconditionFailed   = errors.New("PreconditionFailed")
+	ErrorTooManyRequests      = errors.New("TooManyRequests")
+	ErrorUnprocessableEntity  = errors.New("UnprocessableEntity")
+	ErrorPayloadTooLarge      = errors.New("PayloadTooLarge")
+	ErrorUnavailable          = errors.New("Unavailable")
 )
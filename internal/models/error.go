@@ -2,6 +2,32 @@ package models
 
 // easyjson -all ./internal/models/error.go
 
-type Error struct {
+// Machine-readable error codes, clients should branch on these rather than
+// on Message.
+const (
+	CodeBadRequest   = "bad_request"
+	CodeForbidden    = "forbidden"
+	CodeUnauthorized = "unauthorized"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeInternal     = "internal"
+
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeRateLimited          = "rate_limited"
+	CodeUnprocessableEntity  = "unprocessable_entity"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnavailable          = "unavailable"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+type Error struct {
+	Code     string       `json:"code,omitempty"`
+	Message  string       `json:"message"`
+	Resource string       `json:"resource,omitempty"`
+	Details  []FieldError `json:"details,omitempty"`
+}
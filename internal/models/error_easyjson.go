@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlexer.Lexer, out *Error) {
+func easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlexer.Lexer, out *FieldError) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -36,6 +36,8 @@ func easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jle
 			continue
 		}
 		switch key {
+		case "field":
+			out.Field = string(in.String())
 		case "message":
 			out.Message = string(in.String())
 		default:
@@ -48,38 +50,166 @@ func easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jle
 		in.Consumed()
 	}
 }
-func easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwriter.Writer, in Error) {
+func easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwriter.Writer, in FieldError) {
 	out.RawByte('{')
 	first := true
 	_ = first
+	{
+		const prefix string = ",\"field\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Field))
+	}
 	{
 		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v FieldError) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v FieldError) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *FieldError) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *FieldError) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(l, v)
+}
+func easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jlexer.Lexer, out *Error) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "code":
+			out.Code = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		case "resource":
+			out.Resource = string(in.String())
+		case "details":
+			if in.IsNull() {
+				in.Skip()
+				out.Details = nil
+			} else {
+				in.Delim('[')
+				if out.Details == nil {
+					if !in.IsDelim(']') {
+						out.Details = make([]FieldError, 0, 2)
+					} else {
+						out.Details = []FieldError{}
+					}
+				} else {
+					out.Details = (out.Details)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 FieldError
+					(v1).UnmarshalEasyJSON(in)
+					out.Details = append(out.Details, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *jwriter.Writer, in Error) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Code != "" {
+		const prefix string = ",\"code\":"
+		first = false
 		out.RawString(prefix[1:])
+		out.String(string(in.Code))
+	}
+	{
+		const prefix string = ",\"message\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
 		out.String(string(in.Message))
 	}
+	if in.Resource != "" {
+		const prefix string = ",\"resource\":"
+		out.RawString(prefix)
+		out.String(string(in.Resource))
+	}
+	if len(in.Details) != 0 {
+		const prefix string = ",\"details\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Details {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Error) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(&w, v)
+	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Error) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(w, v)
+	easyjsonE34310f8EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Error) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(&r, v)
+	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Error) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(l, v)
+	easyjsonE34310f8DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(l, v)
 }
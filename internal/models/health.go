@@ -0,0 +1,20 @@
+package models
+
+// easyjson -all ./internal/models/health.go
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Message   string  `json:"message,omitempty"`
+}
+
+type Health struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
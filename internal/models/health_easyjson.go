@@ -0,0 +1,211 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlexer.Lexer, out *HealthCheck) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "name":
+			out.Name = string(in.String())
+		case "status":
+			out.Status = string(in.String())
+		case "latency_ms":
+			out.LatencyMs = float64(in.Float64())
+		case "message":
+			out.Message = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwriter.Writer, in HealthCheck) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"name\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Name))
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"latency_ms\":"
+		out.RawString(prefix)
+		out.Float64(float64(in.LatencyMs))
+	}
+	if in.Message != "" {
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v HealthCheck) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v HealthCheck) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *HealthCheck) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *HealthCheck) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels(l, v)
+}
+func easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jlexer.Lexer, out *Health) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "status":
+			out.Status = string(in.String())
+		case "checks":
+			if in.IsNull() {
+				in.Skip()
+				out.Checks = nil
+			} else {
+				in.Delim('[')
+				if out.Checks == nil {
+					if !in.IsDelim(']') {
+						out.Checks = make([]HealthCheck, 0, 1)
+					} else {
+						out.Checks = []HealthCheck{}
+					}
+				} else {
+					out.Checks = (out.Checks)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 HealthCheck
+					(v1).UnmarshalEasyJSON(in)
+					out.Checks = append(out.Checks, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *jwriter.Writer, in Health) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"checks\":"
+		out.RawString(prefix)
+		if in.Checks == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Checks {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Health) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Health) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson53c2c5caEncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Health) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Health) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson53c2c5caDecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(l, v)
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+//easyjson -all ./internal/models/page.go
+
+// PageKey is the position of a row in a /api/v2 listing. It holds the full
+// sort key, so rows sharing a created time still have a strict order. Only
+// the fields of the listing's sort key are set.
+type PageKey struct {
+	Created  time.Time `json:"c,omitempty"`
+	ID       int       `json:"i,omitempty"`
+	Path     []int64   `json:"p,omitempty"`
+	Nickname string    `json:"n,omitempty"`
+}
+
+// Equal reports whether both keys name the same position.
+func (k PageKey) Equal(other PageKey) bool {
+	if !k.Created.Equal(other.Created) || k.ID != other.ID || k.Nickname != other.Nickname || len(k.Path) != len(other.Path) {
+		return false
+	}
+	for i := range k.Path {
+		if k.Path[i] != other.Path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type ThreadsPage struct {
+	Items      []Thread `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
+}
+
+type PostsPage struct {
+	Items      []Post `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+type UsersPage struct {
+	Items      []User `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,780 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	pgtype "github.com/jackc/pgx/pgtype"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlexer.Lexer, out *UsersPage) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "items":
+			if in.IsNull() {
+				in.Skip()
+				out.Items = nil
+			} else {
+				in.Delim('[')
+				if out.Items == nil {
+					if !in.IsDelim(']') {
+						out.Items = make([]User, 0, 0)
+					} else {
+						out.Items = []User{}
+					}
+				} else {
+					out.Items = (out.Items)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 User
+					(v1).UnmarshalEasyJSON(in)
+					out.Items = append(out.Items, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next_cursor":
+			out.NextCursor = string(in.String())
+		case "prev_cursor":
+			out.PrevCursor = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwriter.Writer, in UsersPage) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"items\":"
+		out.RawString(prefix[1:])
+		if in.Items == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v2, v3 := range in.Items {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.NextCursor != "" {
+		const prefix string = ",\"next_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.NextCursor))
+	}
+	if in.PrevCursor != "" {
+		const prefix string = ",\"prev_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.PrevCursor))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v UsersPage) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v UsersPage) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *UsersPage) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *UsersPage) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(l, v)
+}
+func easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jlexer.Lexer, out *ThreadsPage) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "items":
+			if in.IsNull() {
+				in.Skip()
+				out.Items = nil
+			} else {
+				in.Delim('[')
+				if out.Items == nil {
+					if !in.IsDelim(']') {
+						out.Items = make([]Thread, 0, 0)
+					} else {
+						out.Items = []Thread{}
+					}
+				} else {
+					out.Items = (out.Items)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 Thread
+					(v4).UnmarshalEasyJSON(in)
+					out.Items = append(out.Items, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next_cursor":
+			out.NextCursor = string(in.String())
+		case "prev_cursor":
+			out.PrevCursor = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *jwriter.Writer, in ThreadsPage) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"items\":"
+		out.RawString(prefix[1:])
+		if in.Items == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Items {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				(v6).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.NextCursor != "" {
+		const prefix string = ",\"next_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.NextCursor))
+	}
+	if in.PrevCursor != "" {
+		const prefix string = ",\"prev_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.PrevCursor))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadsPage) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadsPage) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadsPage) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadsPage) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(l, v)
+}
+func easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(in *jlexer.Lexer, out *PostsPage) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "items":
+			if in.IsNull() {
+				in.Skip()
+				out.Items = nil
+			} else {
+				in.Delim('[')
+				if out.Items == nil {
+					if !in.IsDelim(']') {
+						out.Items = make([]Post, 0, 0)
+					} else {
+						out.Items = []Post{}
+					}
+				} else {
+					out.Items = (out.Items)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v7 Post
+					easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels3(in, &v7)
+					out.Items = append(out.Items, v7)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "next_cursor":
+			out.NextCursor = string(in.String())
+		case "prev_cursor":
+			out.PrevCursor = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(out *jwriter.Writer, in PostsPage) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"items\":"
+		out.RawString(prefix[1:])
+		if in.Items == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v8, v9 := range in.Items {
+				if v8 > 0 {
+					out.RawByte(',')
+				}
+				easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels3(out, v9)
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.NextCursor != "" {
+		const prefix string = ",\"next_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.NextCursor))
+	}
+	if in.PrevCursor != "" {
+		const prefix string = ",\"prev_cursor\":"
+		out.RawString(prefix)
+		out.String(string(in.PrevCursor))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostsPage) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostsPage) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostsPage) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostsPage) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(l, v)
+}
+func easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels3(in *jlexer.Lexer, out *Post) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "parent":
+			out.Parent = int(in.Int())
+		case "author":
+			out.Author = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		case "isEdited":
+			out.IsEdited = bool(in.Bool())
+		case "forum":
+			out.Forum = string(in.String())
+		case "thread":
+			out.Thread = int(in.Int())
+		case "created":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		case "path":
+			easyjson7d177735DecodeGithubComJackcPgxPgtype(in, &out.Path)
+		case "version":
+			out.Version = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels3(out *jwriter.Writer, in Post) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.ID != 0 {
+		const prefix string = ",\"id\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	if in.Parent != 0 {
+		const prefix string = ",\"parent\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.Parent))
+	}
+	{
+		const prefix string = ",\"author\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Author))
+	}
+	{
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	if in.IsEdited {
+		const prefix string = ",\"isEdited\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.IsEdited))
+	}
+	if in.Forum != "" {
+		const prefix string = ",\"forum\":"
+		out.RawString(prefix)
+		out.String(string(in.Forum))
+	}
+	if in.Thread != 0 {
+		const prefix string = ",\"thread\":"
+		out.RawString(prefix)
+		out.Int(int(in.Thread))
+	}
+	if true {
+		const prefix string = ",\"created\":"
+		out.RawString(prefix)
+		out.Raw((in.Created).MarshalJSON())
+	}
+	if true {
+		const prefix string = ",\"path\":"
+		out.RawString(prefix)
+		easyjson7d177735EncodeGithubComJackcPgxPgtype(out, in.Path)
+	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
+	out.RawByte('}')
+}
+func easyjson7d177735DecodeGithubComJackcPgxPgtype(in *jlexer.Lexer, out *pgtype.Int8Array) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Elements":
+			if in.IsNull() {
+				in.Skip()
+				out.Elements = nil
+			} else {
+				in.Delim('[')
+				if out.Elements == nil {
+					if !in.IsDelim(']') {
+						out.Elements = make([]pgtype.Int8, 0, 4)
+					} else {
+						out.Elements = []pgtype.Int8{}
+					}
+				} else {
+					out.Elements = (out.Elements)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v10 pgtype.Int8
+					if data := in.Raw(); in.Ok() {
+						in.AddError((v10).UnmarshalJSON(data))
+					}
+					out.Elements = append(out.Elements, v10)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "Dimensions":
+			if in.IsNull() {
+				in.Skip()
+				out.Dimensions = nil
+			} else {
+				in.Delim('[')
+				if out.Dimensions == nil {
+					if !in.IsDelim(']') {
+						out.Dimensions = make([]pgtype.ArrayDimension, 0, 8)
+					} else {
+						out.Dimensions = []pgtype.ArrayDimension{}
+					}
+				} else {
+					out.Dimensions = (out.Dimensions)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v11 pgtype.ArrayDimension
+					easyjson7d177735DecodeGithubComJackcPgxPgtype1(in, &v11)
+					out.Dimensions = append(out.Dimensions, v11)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "Status":
+			out.Status = pgtype.Status(in.Uint8())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComJackcPgxPgtype(out *jwriter.Writer, in pgtype.Int8Array) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Elements\":"
+		out.RawString(prefix[1:])
+		if in.Elements == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v12, v13 := range in.Elements {
+				if v12 > 0 {
+					out.RawByte(',')
+				}
+				out.Raw((v13).MarshalJSON())
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"Dimensions\":"
+		out.RawString(prefix)
+		if in.Dimensions == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v14, v15 := range in.Dimensions {
+				if v14 > 0 {
+					out.RawByte(',')
+				}
+				easyjson7d177735EncodeGithubComJackcPgxPgtype1(out, v15)
+			}
+			out.RawByte(']')
+		}
+	}
+	{
+		const prefix string = ",\"Status\":"
+		out.RawString(prefix)
+		out.Uint8(uint8(in.Status))
+	}
+	out.RawByte('}')
+}
+func easyjson7d177735DecodeGithubComJackcPgxPgtype1(in *jlexer.Lexer, out *pgtype.ArrayDimension) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "Length":
+			out.Length = int32(in.Int32())
+		case "LowerBound":
+			out.LowerBound = int32(in.Int32())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComJackcPgxPgtype1(out *jwriter.Writer, in pgtype.ArrayDimension) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"Length\":"
+		out.RawString(prefix[1:])
+		out.Int32(int32(in.Length))
+	}
+	{
+		const prefix string = ",\"LowerBound\":"
+		out.RawString(prefix)
+		out.Int32(int32(in.LowerBound))
+	}
+	out.RawByte('}')
+}
+func easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels4(in *jlexer.Lexer, out *PageKey) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "c":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		case "i":
+			out.ID = int(in.Int())
+		case "p":
+			if in.IsNull() {
+				in.Skip()
+				out.Path = nil
+			} else {
+				in.Delim('[')
+				if out.Path == nil {
+					if !in.IsDelim(']') {
+						out.Path = make([]int64, 0, 8)
+					} else {
+						out.Path = []int64{}
+					}
+				} else {
+					out.Path = (out.Path)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v16 int64
+					v16 = int64(in.Int64())
+					out.Path = append(out.Path, v16)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "n":
+			out.Nickname = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels4(out *jwriter.Writer, in PageKey) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if true {
+		const prefix string = ",\"c\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Raw((in.Created).MarshalJSON())
+	}
+	if in.ID != 0 {
+		const prefix string = ",\"i\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Int(int(in.ID))
+	}
+	if len(in.Path) != 0 {
+		const prefix string = ",\"p\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v17, v18 := range in.Path {
+				if v17 > 0 {
+					out.RawByte(',')
+				}
+				out.Int64(int64(v18))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Nickname != "" {
+		const prefix string = ",\"n\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Nickname))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PageKey) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels4(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PageKey) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson7d177735EncodeGithubComQqq4uTPDBMSTermProjectInternalModels4(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PageKey) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels4(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PageKey) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson7d177735DecodeGithubComQqq4uTPDBMSTermProjectInternalModels4(l, v)
+}
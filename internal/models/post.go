@@ -17,6 +17,18 @@ type Post struct {
 	Thread   int              `json:"thread,omitempty"`
 	Created  time.Time        `json:"created,omitempty"`
 	Path     pgtype.Int8Array `json:"path,omitempty"`
+	Version  int              `json:"version,omitempty"`
+	// Modified is the time of the last edit, Created if there was none. It
+	// is only filled in by the single-post queries.
+	Modified time.Time `json:"-"`
+}
+
+// LastModified is the Last-Modified time of the post representation.
+func (p Post) LastModified() time.Time {
+	if p.Modified.After(p.Created) {
+		return p.Modified
+	}
+	return p.Created
 }
 
 //easyjson:json
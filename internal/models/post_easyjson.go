@@ -123,6 +123,8 @@ func easyjson5a72dc82DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jl
 			}
 		case "path":
 			easyjson5a72dc82DecodeGithubComJackcPgxPgtype(in, &out.Path)
+		case "version":
+			out.Version = int(in.Int())
 		default:
 			in.SkipRecursive()
 		}
@@ -193,6 +195,11 @@ func easyjson5a72dc82EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *j
 		out.RawString(prefix)
 		easyjson5a72dc82EncodeGithubComJackcPgxPgtype(out, in.Path)
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
 	out.RawByte('}')
 }
 func easyjson5a72dc82DecodeGithubComJackcPgxPgtype(in *jlexer.Lexer, out *pgtype.Int8Array) {
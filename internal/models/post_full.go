@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // easyjson -all ./internal/models/post_full.go
 
 type PostFull struct {
@@ -8,3 +10,17 @@ type PostFull struct {
 	Author *User   `json:"author,omitempty"`
 	Post   Post    `json:"post,omitempty"`
 }
+
+// LastModified covers the post and its thread. Users and forums carry no
+// timestamps, so it is zero when either is included and only the ETag can
+// tell whether the representation changed.
+func (p PostFull) LastModified() time.Time {
+	if p.Author != nil || p.Forum != nil {
+		return time.Time{}
+	}
+	lastModified := p.Post.LastModified()
+	if p.Thread != nil && p.Thread.LastModified().After(lastModified) {
+		lastModified = p.Thread.LastModified()
+	}
+	return lastModified
+}
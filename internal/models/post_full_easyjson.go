@@ -184,6 +184,8 @@ func easyjson95e8944cDecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jl
 			}
 		case "path":
 			easyjson95e8944cDecodeGithubComJackcPgxPgtype(in, &out.Path)
+		case "version":
+			out.Version = int(in.Int())
 		default:
 			in.SkipRecursive()
 		}
@@ -254,6 +256,11 @@ func easyjson95e8944cEncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *j
 		out.RawString(prefix)
 		easyjson95e8944cEncodeGithubComJackcPgxPgtype(out, in.Path)
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
 	out.RawByte('}')
 }
 func easyjson95e8944cDecodeGithubComJackcPgxPgtype(in *jlexer.Lexer, out *pgtype.Int8Array) {
@@ -5,4 +5,7 @@ package models
 type PostUpdate struct {
 	ID      int    `json:"id,omitempty"`
 	Message string `json:"message,omitempty"`
+	// Version is the version the client expects to overwrite, 0 for an
+	// unconditional update. It comes from If-Match, not from the body.
+	Version int `json:"-"`
 }
@@ -13,4 +13,16 @@ type Thread struct {
 	Votes   int       `json:"votes,omitempty"`
 	Slug    string    `json:"slug,omitempty"`
 	Created time.Time `json:"created,omitempty"`
+	Version int       `json:"version,omitempty"`
+	// Modified is the time of the last change, Created if there was none.
+	// It is only filled in by the single-thread queries.
+	Modified time.Time `json:"-"`
+}
+
+// LastModified is the Last-Modified time of the thread representation.
+func (t Thread) LastModified() time.Time {
+	if t.Modified.After(t.Created) {
+		return t.Modified
+	}
+	return t.Created
 }
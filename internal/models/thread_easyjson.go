@@ -54,6 +54,8 @@ func easyjson2d00218DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlex
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.Created).UnmarshalJSON(data))
 			}
+		case "version":
+			out.Version = int(in.Int())
 		default:
 			in.SkipRecursive()
 		}
@@ -114,6 +116,11 @@ func easyjson2d00218EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwr
 		out.RawString(prefix)
 		out.Raw((in.Created).MarshalJSON())
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
 	out.RawByte('}')
 }
 
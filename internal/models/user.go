@@ -8,4 +8,8 @@ type User struct {
 	Fullname string `json:"fullname"`
 	About    string `json:"about,omitempty"`
 	Email    string `json:"email"`
+	Version  int    `json:"version,omitempty"`
+	// Password is only read, when a user is created. The usecase replaces
+	// it with its hash before the user reaches the repository.
+	Password string `json:"password,omitempty"`
 }
@@ -44,6 +44,10 @@ func easyjson9e1087fdDecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jle
 			out.About = string(in.String())
 		case "email":
 			out.Email = string(in.String())
+		case "version":
+			out.Version = int(in.Int())
+		case "password":
+			out.Password = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -88,6 +92,16 @@ func easyjson9e1087fdEncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jw
 		out.RawString(prefix)
 		out.String(string(in.Email))
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
+	if in.Password != "" {
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
 	out.RawByte('}')
 }
 
@@ -0,0 +1,22 @@
+package models
+
+import "strings"
+
+// ValidationError lists every invalid field of a request. It matches
+// ErrorBadRequest so callers that only care about the class of error can use
+// errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		problems = append(problems, field.Field+": "+field.Message)
+	}
+	return "Invalid request: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrorBadRequest
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//easyjson -all ./internal/models/webhook.go
+
+// Webhook events. Every delivery body is a WebhookPayload whose data is the
+// created thread, the created posts, the edited post or the voted thread.
+const (
+	WebhookThreadCreated = "thread-created"
+	WebhookPostsCreated  = "posts-created"
+	WebhookPostEdited    = "post-edited"
+	WebhookVote          = "vote"
+)
+
+// Delivery statuses: pending until a delivery succeeds or runs out of
+// attempts and is dead-lettered.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	ID     int      `json:"id,omitempty"`
+	Forum  string   `json:"forum"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret keys the delivery signatures. It is only returned by the
+	// request that creates the webhook.
+	Secret  string    `json:"secret,omitempty"`
+	Active  bool      `json:"active"`
+	Created time.Time `json:"created,omitempty"`
+}
+
+// WebhookUpdate changes the fields that are set.
+type WebhookUpdate struct {
+	ID     int      `json:"-"`
+	Forum  string   `json:"-"`
+	URL    string   `json:"url,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID          int64           `json:"id"`
+	Webhook     int             `json:"webhook"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt *time.Time      `json:"next_attempt,omitempty"`
+	// LastStatus is the HTTP status of the last attempt, 0 when it got no
+	// response.
+	LastStatus int        `json:"last_status,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Created    time.Time  `json:"created"`
+	Delivered  *time.Time `json:"delivered,omitempty"`
+}
+
+// WebhookPayload is the body of a delivery.
+type WebhookPayload struct {
+	Event    string      `json:"event"`
+	Forum    string      `json:"forum"`
+	Occurred time.Time   `json:"occurred"`
+	Data     interface{} `json:"data"`
+}
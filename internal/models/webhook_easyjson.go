@@ -0,0 +1,574 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(in *jlexer.Lexer, out *WebhookUpdate) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "url":
+			out.URL = string(in.String())
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]string, 0, 4)
+					} else {
+						out.Events = []string{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 string
+					v1 = string(in.String())
+					out.Events = append(out.Events, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "secret":
+			out.Secret = string(in.String())
+		case "active":
+			if in.IsNull() {
+				in.Skip()
+				out.Active = nil
+			} else {
+				if out.Active == nil {
+					out.Active = new(bool)
+				}
+				*out.Active = bool(in.Bool())
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(out *jwriter.Writer, in WebhookUpdate) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.URL != "" {
+		const prefix string = ",\"url\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.URL))
+	}
+	if len(in.Events) != 0 {
+		const prefix string = ",\"events\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Events {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v3))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Secret != "" {
+		const prefix string = ",\"secret\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Secret))
+	}
+	if in.Active != nil {
+		const prefix string = ",\"active\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(*in.Active))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookUpdate) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookUpdate) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookUpdate) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookUpdate) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels(l, v)
+}
+func easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(in *jlexer.Lexer, out *WebhookPayload) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "event":
+			out.Event = string(in.String())
+		case "forum":
+			out.Forum = string(in.String())
+		case "occurred":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Occurred).UnmarshalJSON(data))
+			}
+		case "data":
+			if m, ok := out.Data.(easyjson.Unmarshaler); ok {
+				m.UnmarshalEasyJSON(in)
+			} else if m, ok := out.Data.(json.Unmarshaler); ok {
+				_ = m.UnmarshalJSON(in.Raw())
+			} else {
+				out.Data = in.Interface()
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(out *jwriter.Writer, in WebhookPayload) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"event\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Event))
+	}
+	{
+		const prefix string = ",\"forum\":"
+		out.RawString(prefix)
+		out.String(string(in.Forum))
+	}
+	{
+		const prefix string = ",\"occurred\":"
+		out.RawString(prefix)
+		out.Raw((in.Occurred).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"data\":"
+		out.RawString(prefix)
+		if m, ok := in.Data.(easyjson.Marshaler); ok {
+			m.MarshalEasyJSON(out)
+		} else if m, ok := in.Data.(json.Marshaler); ok {
+			out.Raw(m.MarshalJSON())
+		} else {
+			out.Raw(json.Marshal(in.Data))
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookPayload) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookPayload) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookPayload) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookPayload) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels1(l, v)
+}
+func easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(in *jlexer.Lexer, out *WebhookDelivery) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int64(in.Int64())
+		case "webhook":
+			out.Webhook = int(in.Int())
+		case "event":
+			out.Event = string(in.String())
+		case "payload":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Payload).UnmarshalJSON(data))
+			}
+		case "status":
+			out.Status = string(in.String())
+		case "attempts":
+			out.Attempts = int(in.Int())
+		case "next_attempt":
+			if in.IsNull() {
+				in.Skip()
+				out.NextAttempt = nil
+			} else {
+				if out.NextAttempt == nil {
+					out.NextAttempt = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.NextAttempt).UnmarshalJSON(data))
+				}
+			}
+		case "last_status":
+			out.LastStatus = int(in.Int())
+		case "last_error":
+			out.LastError = string(in.String())
+		case "created":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		case "delivered":
+			if in.IsNull() {
+				in.Skip()
+				out.Delivered = nil
+			} else {
+				if out.Delivered == nil {
+					out.Delivered = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.Delivered).UnmarshalJSON(data))
+				}
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(out *jwriter.Writer, in WebhookDelivery) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"id\":"
+		out.RawString(prefix[1:])
+		out.Int64(int64(in.ID))
+	}
+	{
+		const prefix string = ",\"webhook\":"
+		out.RawString(prefix)
+		out.Int(int(in.Webhook))
+	}
+	{
+		const prefix string = ",\"event\":"
+		out.RawString(prefix)
+		out.String(string(in.Event))
+	}
+	{
+		const prefix string = ",\"payload\":"
+		out.RawString(prefix)
+		out.Raw((in.Payload).MarshalJSON())
+	}
+	{
+		const prefix string = ",\"status\":"
+		out.RawString(prefix)
+		out.String(string(in.Status))
+	}
+	{
+		const prefix string = ",\"attempts\":"
+		out.RawString(prefix)
+		out.Int(int(in.Attempts))
+	}
+	if in.NextAttempt != nil {
+		const prefix string = ",\"next_attempt\":"
+		out.RawString(prefix)
+		out.Raw((*in.NextAttempt).MarshalJSON())
+	}
+	if in.LastStatus != 0 {
+		const prefix string = ",\"last_status\":"
+		out.RawString(prefix)
+		out.Int(int(in.LastStatus))
+	}
+	if in.LastError != "" {
+		const prefix string = ",\"last_error\":"
+		out.RawString(prefix)
+		out.String(string(in.LastError))
+	}
+	{
+		const prefix string = ",\"created\":"
+		out.RawString(prefix)
+		out.Raw((in.Created).MarshalJSON())
+	}
+	if in.Delivered != nil {
+		const prefix string = ",\"delivered\":"
+		out.RawString(prefix)
+		out.Raw((*in.Delivered).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v WebhookDelivery) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v WebhookDelivery) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *WebhookDelivery) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *WebhookDelivery) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels2(l, v)
+}
+func easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels3(in *jlexer.Lexer, out *Webhook) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = int(in.Int())
+		case "forum":
+			out.Forum = string(in.String())
+		case "url":
+			out.URL = string(in.String())
+		case "events":
+			if in.IsNull() {
+				in.Skip()
+				out.Events = nil
+			} else {
+				in.Delim('[')
+				if out.Events == nil {
+					if !in.IsDelim(']') {
+						out.Events = make([]string, 0, 4)
+					} else {
+						out.Events = []string{}
+					}
+				} else {
+					out.Events = (out.Events)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 string
+					v4 = string(in.String())
+					out.Events = append(out.Events, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		case "secret":
+			out.Secret = string(in.String())
+		case "active":
+			out.Active = bool(in.Bool())
+		case "created":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels3(out *jwriter.Writer, in Webhook) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.ID != 0 {
+		const prefix string = ",\"id\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Int(int(in.ID))
+	}
+	{
+		const prefix string = ",\"forum\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Forum))
+	}
+	{
+		const prefix string = ",\"url\":"
+		out.RawString(prefix)
+		out.String(string(in.URL))
+	}
+	{
+		const prefix string = ",\"events\":"
+		out.RawString(prefix)
+		if in.Events == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v5, v6 := range in.Events {
+				if v5 > 0 {
+					out.RawByte(',')
+				}
+				out.String(string(v6))
+			}
+			out.RawByte(']')
+		}
+	}
+	if in.Secret != "" {
+		const prefix string = ",\"secret\":"
+		out.RawString(prefix)
+		out.String(string(in.Secret))
+	}
+	{
+		const prefix string = ",\"active\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Active))
+	}
+	if true {
+		const prefix string = ",\"created\":"
+		out.RawString(prefix)
+		out.Raw((in.Created).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Webhook) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Webhook) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson3f91c269EncodeGithubComQqq4uTPDBMSTermProjectInternalModels3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Webhook) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Webhook) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson3f91c269DecodeGithubComQqq4uTPDBMSTermProjectInternalModels3(l, v)
+}
@@ -0,0 +1,41 @@
+// Package openapi serves the OpenAPI 3 description of the HTTP API and can
+// check live traffic against it.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"github.com/getkin/kin-openapi/openapi3"
+	"net/http"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+//go:embed docs.html
+var docs []byte
+
+// Load parses the embedded document and checks that it is a valid OpenAPI 3
+// specification.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	_, _ = w.Write(spec)
+}
+
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docs)
+}
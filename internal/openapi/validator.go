@@ -0,0 +1,163 @@
+package openapi
+
+import (
+	"bytes"
+	"errors"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Validator rejects requests that do not match the specification with 400.
+// With validateResponses it also buffers every response and replaces the
+// ones that break the specification with 500; that is meant for tests, not
+// production, since it defeats streaming.
+type Validator struct {
+	router            routers.Router
+	validateResponses bool
+	options           *openapi3filter.Options
+}
+
+func NewValidator(doc *openapi3.T, validateResponses bool) (*Validator, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{
+		router:            router,
+		validateResponses: validateResponses,
+		options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}, nil
+}
+
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// Unknown routes and methods are left to the application router.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The specification describes the JSON representation only, so
+		// MessagePack and Protobuf bodies are not checked.
+		options := *v.options
+		if codec, err := utils.RequestCodec(r); err == nil && codec != utils.JSON {
+			options.ExcludeRequestBody = true
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &options,
+		}
+		if err = openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			utils.ErrorResponse(w, r, requestError(err), "", "")
+			return
+		}
+
+		if !v.validateResponses || streaming(route.Operation) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &bufferedWriter{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		responseOptions := options
+		if !strings.HasPrefix(recorder.header.Get("Content-Type"), utils.ContentTypeJSON) {
+			responseOptions.ExcludeResponseBody = true
+		}
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 recorder.status,
+			Header:                 recorder.header,
+			Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+			Options:                &responseOptions,
+		})
+		if err != nil {
+			log.Printf("response to %s %s violates the API specification: %v", r.Method, r.URL.Path, err)
+			status, body := utils.NewError(models.ErrorInternal, "", "")
+			body.Message = "Response does not match the API specification: " + err.Error()
+			utils.Response(w, r, status, body)
+			return
+		}
+		recorder.copyTo(w)
+	})
+}
+
+// streaming reports whether op answers with an event stream or a protocol
+// switch, neither of which can be buffered for validation.
+func streaming(op *openapi3.Operation) bool {
+	if op.Responses.Status(http.StatusSwitchingProtocols) != nil {
+		return true
+	}
+	ok := op.Responses.Status(http.StatusOK)
+	return ok != nil && ok.Value != nil && ok.Value.Content.Get("text/event-stream") != nil
+}
+
+func requestError(err error) error {
+	field := "request"
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) {
+		switch {
+		case requestErr.Parameter != nil:
+			field = requestErr.Parameter.Name
+		case requestErr.RequestBody != nil:
+			field = "body"
+		}
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 && field == "body" {
+			field = strings.Join(pointer, ".")
+		}
+		return &models.ValidationError{Fields: []models.FieldError{{Field: field, Message: schemaErr.Reason}}}
+	}
+	message := err.Error()
+	if requestErr != nil && requestErr.Err != nil {
+		message = requestErr.Err.Error()
+	} else if requestErr != nil && requestErr.Reason != "" {
+		message = requestErr.Reason
+	}
+	return &models.ValidationError{Fields: []models.FieldError{{Field: field, Message: message}}}
+}
+
+type bufferedWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) copyTo(dst http.ResponseWriter) {
+	for key, values := range w.header {
+		dst.Header()[key] = values
+	}
+	dst.WriteHeader(w.status)
+	_, _ = dst.Write(w.body.Bytes())
+}
@@ -0,0 +1,255 @@
+package pb
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func FromUser(u models.User) *User {
+	return &User{Nickname: u.Nickname, Fullname: u.Fullname, About: u.About, Email: u.Email, Version: int64(u.Version)}
+}
+
+func (x *User) Model() models.User {
+	return models.User{Nickname: x.GetNickname(), Fullname: x.GetFullname(), About: x.GetAbout(), Email: x.GetEmail(), Version: int(x.GetVersion()), Password: x.GetPassword()}
+}
+
+func FromUsers(users []models.User) *UserList {
+	result := &UserList{Items: make([]*User, 0, len(users))}
+	for _, u := range users {
+		result.Items = append(result.Items, FromUser(u))
+	}
+	return result
+}
+
+func (x *UserList) Model() []models.User {
+	result := make([]models.User, 0, len(x.GetItems()))
+	for _, u := range x.GetItems() {
+		result = append(result, u.Model())
+	}
+	return result
+}
+
+func FromForum(f models.Forum) *Forum {
+	return &Forum{Title: f.Title, User: f.User, Slug: f.Slug, Posts: int64(f.Posts), Threads: int64(f.Threads)}
+}
+
+func (x *Forum) Model() models.Forum {
+	return models.Forum{Title: x.GetTitle(), User: x.GetUser(), Slug: x.GetSlug(), Posts: int(x.GetPosts()), Threads: int(x.GetThreads())}
+}
+
+func FromForums(forums []models.Forum) *ForumList {
+	result := &ForumList{Items: make([]*Forum, 0, len(forums))}
+	for _, f := range forums {
+		result.Items = append(result.Items, FromForum(f))
+	}
+	return result
+}
+
+func (x *ForumList) Model() []models.Forum {
+	result := make([]models.Forum, 0, len(x.GetItems()))
+	for _, f := range x.GetItems() {
+		result = append(result, f.Model())
+	}
+	return result
+}
+
+func FromThread(t models.Thread) *Thread {
+	return &Thread{
+		Id:      int64(t.ID),
+		Title:   t.Title,
+		Author:  t.Author,
+		Forum:   t.Forum,
+		Message: t.Message,
+		Votes:   int64(t.Votes),
+		Slug:    t.Slug,
+		Created: timestamp(t.Created),
+		Version: int64(t.Version),
+	}
+}
+
+func (x *Thread) Model() models.Thread {
+	return models.Thread{
+		ID:      int(x.GetId()),
+		Title:   x.GetTitle(),
+		Author:  x.GetAuthor(),
+		Forum:   x.GetForum(),
+		Message: x.GetMessage(),
+		Votes:   int(x.GetVotes()),
+		Slug:    x.GetSlug(),
+		Created: fromTimestamp(x.GetCreated()),
+		Version: int(x.GetVersion()),
+	}
+}
+
+func FromThreads(threads []models.Thread) *ThreadList {
+	result := &ThreadList{Items: make([]*Thread, 0, len(threads))}
+	for _, t := range threads {
+		result.Items = append(result.Items, FromThread(t))
+	}
+	return result
+}
+
+func (x *ThreadList) Model() []models.Thread {
+	result := make([]models.Thread, 0, len(x.GetItems()))
+	for _, t := range x.GetItems() {
+		result = append(result, t.Model())
+	}
+	return result
+}
+
+func FromPost(p models.Post) *Post {
+	return &Post{
+		Id:       int64(p.ID),
+		Parent:   int64(p.Parent),
+		Author:   p.Author,
+		Message:  p.Message,
+		IsEdited: p.IsEdited,
+		Forum:    p.Forum,
+		Thread:   int64(p.Thread),
+		Created:  timestamp(p.Created),
+		Version:  int64(p.Version),
+	}
+}
+
+func (x *Post) Model() models.Post {
+	return models.Post{
+		ID:       int(x.GetId()),
+		Parent:   int(x.GetParent()),
+		Author:   x.GetAuthor(),
+		Message:  x.GetMessage(),
+		IsEdited: x.GetIsEdited(),
+		Forum:    x.GetForum(),
+		Thread:   int(x.GetThread()),
+		Created:  fromTimestamp(x.GetCreated()),
+		Version:  int(x.GetVersion()),
+	}
+}
+
+func FromPosts(posts []models.Post) *PostList {
+	result := &PostList{Items: make([]*Post, 0, len(posts))}
+	for _, p := range posts {
+		result.Items = append(result.Items, FromPost(p))
+	}
+	return result
+}
+
+func (x *PostList) Model() models.PostsList {
+	result := make(models.PostsList, 0, len(x.GetItems()))
+	for _, p := range x.GetItems() {
+		result = append(result, p.Model())
+	}
+	return result
+}
+
+func FromPostFull(p models.PostFull) *PostFull {
+	result := &PostFull{Post: FromPost(p.Post)}
+	if p.Author != nil {
+		result.Author = FromUser(*p.Author)
+	}
+	if p.Forum != nil {
+		result.Forum = FromForum(*p.Forum)
+	}
+	if p.Thread != nil {
+		result.Thread = FromThread(*p.Thread)
+	}
+	return result
+}
+
+func (x *PostFull) Model() models.PostFull {
+	result := models.PostFull{Post: x.GetPost().Model()}
+	if x.GetAuthor() != nil {
+		author := x.GetAuthor().Model()
+		result.Author = &author
+	}
+	if x.GetForum() != nil {
+		forum := x.GetForum().Model()
+		result.Forum = &forum
+	}
+	if x.GetThread() != nil {
+		thread := x.GetThread().Model()
+		result.Thread = &thread
+	}
+	return result
+}
+
+func FromPostUpdate(p models.PostUpdate) *PostUpdate {
+	return &PostUpdate{Id: int64(p.ID), Message: p.Message}
+}
+
+func (x *PostUpdate) Model() models.PostUpdate {
+	return models.PostUpdate{ID: int(x.GetId()), Message: x.GetMessage()}
+}
+
+func FromVote(v models.Vote) *Vote {
+	return &Vote{Nickname: v.Nickname, Voice: int32(v.Voice)}
+}
+
+func (x *Vote) Model() models.Vote {
+	return models.Vote{Nickname: x.GetNickname(), Voice: int(x.GetVoice())}
+}
+
+func FromStatus(s models.Status) *Status {
+	return &Status{User: s.UsersCount, Forum: s.ForumsCount, Thread: s.ThreadsCount, Post: s.PostsCount}
+}
+
+func (x *Status) Model() models.Status {
+	return models.Status{UsersCount: x.GetUser(), ForumsCount: x.GetForum(), ThreadsCount: x.GetThread(), PostsCount: x.GetPost()}
+}
+
+func FromError(e models.Error) *Error {
+	result := &Error{Code: e.Code, Message: e.Message, Resource: e.Resource}
+	for _, d := range e.Details {
+		result.Details = append(result.Details, &FieldError{Field: d.Field, Message: d.Message})
+	}
+	return result
+}
+
+func (x *Error) Model() models.Error {
+	result := models.Error{Code: x.GetCode(), Message: x.GetMessage(), Resource: x.GetResource()}
+	for _, d := range x.GetDetails() {
+		result.Details = append(result.Details, models.FieldError{Field: d.GetField(), Message: d.GetMessage()})
+	}
+	return result
+}
+
+func FromThreadsPage(p models.ThreadsPage) *ThreadPage {
+	return &ThreadPage{Items: FromThreads(p.Items).GetItems(), NextCursor: p.NextCursor, PrevCursor: p.PrevCursor}
+}
+
+func (x *ThreadPage) Model() models.ThreadsPage {
+	items := (&ThreadList{Items: x.GetItems()}).Model()
+	return models.ThreadsPage{Items: items, NextCursor: x.GetNextCursor(), PrevCursor: x.GetPrevCursor()}
+}
+
+func FromPostsPage(p models.PostsPage) *PostPage {
+	return &PostPage{Items: FromPosts(p.Items).GetItems(), NextCursor: p.NextCursor, PrevCursor: p.PrevCursor}
+}
+
+func (x *PostPage) Model() models.PostsPage {
+	items := (&PostList{Items: x.GetItems()}).Model()
+	return models.PostsPage{Items: items, NextCursor: x.GetNextCursor(), PrevCursor: x.GetPrevCursor()}
+}
+
+func FromUsersPage(p models.UsersPage) *UserPage {
+	return &UserPage{Items: FromUsers(p.Items).GetItems(), NextCursor: p.NextCursor, PrevCursor: p.PrevCursor}
+}
+
+func (x *UserPage) Model() models.UsersPage {
+	items := (&UserList{Items: x.GetItems()}).Model()
+	return models.UsersPage{Items: items, NextCursor: x.GetNextCursor(), PrevCursor: x.GetPrevCursor()}
+}
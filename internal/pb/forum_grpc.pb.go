@@ -0,0 +1,958 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: forum/v1/forum.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ForumService_GetUser_FullMethodName             = "/forum.v1.ForumService/GetUser"
+	ForumService_CreateUser_FullMethodName          = "/forum.v1.ForumService/CreateUser"
+	ForumService_UpdateUser_FullMethodName          = "/forum.v1.ForumService/UpdateUser"
+	ForumService_GetUsers_FullMethodName            = "/forum.v1.ForumService/GetUsers"
+	ForumService_CreateForum_FullMethodName         = "/forum.v1.ForumService/CreateForum"
+	ForumService_GetForum_FullMethodName            = "/forum.v1.ForumService/GetForum"
+	ForumService_CreateThread_FullMethodName        = "/forum.v1.ForumService/CreateThread"
+	ForumService_UpdateThread_FullMethodName        = "/forum.v1.ForumService/UpdateThread"
+	ForumService_GetThreads_FullMethodName          = "/forum.v1.ForumService/GetThreads"
+	ForumService_GetThread_FullMethodName           = "/forum.v1.ForumService/GetThread"
+	ForumService_CreatePosts_FullMethodName         = "/forum.v1.ForumService/CreatePosts"
+	ForumService_Vote_FullMethodName                = "/forum.v1.ForumService/Vote"
+	ForumService_GetPost_FullMethodName             = "/forum.v1.ForumService/GetPost"
+	ForumService_GetThreadPosts_FullMethodName      = "/forum.v1.ForumService/GetThreadPosts"
+	ForumService_UpdatePost_FullMethodName          = "/forum.v1.ForumService/UpdatePost"
+	ForumService_GetThreadsPage_FullMethodName      = "/forum.v1.ForumService/GetThreadsPage"
+	ForumService_GetThreadPostsPage_FullMethodName  = "/forum.v1.ForumService/GetThreadPostsPage"
+	ForumService_GetUsersPage_FullMethodName        = "/forum.v1.ForumService/GetUsersPage"
+	ForumService_GetUsersByNicknames_FullMethodName = "/forum.v1.ForumService/GetUsersByNicknames"
+	ForumService_GetForumsBySlugs_FullMethodName    = "/forum.v1.ForumService/GetForumsBySlugs"
+	ForumService_GetThreadsByIds_FullMethodName     = "/forum.v1.ForumService/GetThreadsByIds"
+	ForumService_GetStatus_FullMethodName           = "/forum.v1.ForumService/GetStatus"
+	ForumService_Clear_FullMethodName               = "/forum.v1.ForumService/Clear"
+)
+
+// ForumServiceClient is the client API for ForumService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ForumServiceClient interface {
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	// version, when set, must match the current one, like If-Match.
+	UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error)
+	GetUsers(ctx context.Context, in *GetUsersRequest, opts ...grpc.CallOption) (*UserList, error)
+	CreateForum(ctx context.Context, in *Forum, opts ...grpc.CallOption) (*Forum, error)
+	GetForum(ctx context.Context, in *GetForumRequest, opts ...grpc.CallOption) (*Forum, error)
+	CreateThread(ctx context.Context, in *Thread, opts ...grpc.CallOption) (*Thread, error)
+	UpdateThread(ctx context.Context, in *UpdateThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	GetThreads(ctx context.Context, in *GetThreadsRequest, opts ...grpc.CallOption) (*ThreadList, error)
+	GetThread(ctx context.Context, in *GetThreadRequest, opts ...grpc.CallOption) (*Thread, error)
+	CreatePosts(ctx context.Context, in *CreatePostsRequest, opts ...grpc.CallOption) (*PostList, error)
+	// Vote returns the thread with the updated vote count.
+	Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*Thread, error)
+	GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*PostFull, error)
+	// GetThreadPosts sends the posts as they are read from the database.
+	GetThreadPosts(ctx context.Context, in *GetThreadPostsRequest, opts ...grpc.CallOption) (ForumService_GetThreadPostsClient, error)
+	UpdatePost(ctx context.Context, in *UpdatePostRequest, opts ...grpc.CallOption) (*Post, error)
+	GetThreadsPage(ctx context.Context, in *GetThreadsPageRequest, opts ...grpc.CallOption) (*ThreadPage, error)
+	GetThreadPostsPage(ctx context.Context, in *GetThreadPostsPageRequest, opts ...grpc.CallOption) (*PostPage, error)
+	GetUsersPage(ctx context.Context, in *GetUsersPageRequest, opts ...grpc.CallOption) (*UserPage, error)
+	GetUsersByNicknames(ctx context.Context, in *GetUsersByNicknamesRequest, opts ...grpc.CallOption) (*UserList, error)
+	GetForumsBySlugs(ctx context.Context, in *GetForumsBySlugsRequest, opts ...grpc.CallOption) (*ForumList, error)
+	GetThreadsByIds(ctx context.Context, in *GetThreadsByIdsRequest, opts ...grpc.CallOption) (*ThreadList, error)
+	GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Status, error)
+	Clear(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type forumServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewForumServiceClient(cc grpc.ClientConnInterface) ForumServiceClient {
+	return &forumServiceClient{cc}
+}
+
+func (c *forumServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, ForumService_GetUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) CreateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, ForumService_CreateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) UpdateUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, ForumService_UpdateUser_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetUsers(ctx context.Context, in *GetUsersRequest, opts ...grpc.CallOption) (*UserList, error) {
+	out := new(UserList)
+	err := c.cc.Invoke(ctx, ForumService_GetUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) CreateForum(ctx context.Context, in *Forum, opts ...grpc.CallOption) (*Forum, error) {
+	out := new(Forum)
+	err := c.cc.Invoke(ctx, ForumService_CreateForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetForum(ctx context.Context, in *GetForumRequest, opts ...grpc.CallOption) (*Forum, error) {
+	out := new(Forum)
+	err := c.cc.Invoke(ctx, ForumService_GetForum_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) CreateThread(ctx context.Context, in *Thread, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ForumService_CreateThread_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) UpdateThread(ctx context.Context, in *UpdateThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ForumService_UpdateThread_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThreads(ctx context.Context, in *GetThreadsRequest, opts ...grpc.CallOption) (*ThreadList, error) {
+	out := new(ThreadList)
+	err := c.cc.Invoke(ctx, ForumService_GetThreads_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThread(ctx context.Context, in *GetThreadRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ForumService_GetThread_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) CreatePosts(ctx context.Context, in *CreatePostsRequest, opts ...grpc.CallOption) (*PostList, error) {
+	out := new(PostList)
+	err := c.cc.Invoke(ctx, ForumService_CreatePosts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) Vote(ctx context.Context, in *VoteRequest, opts ...grpc.CallOption) (*Thread, error) {
+	out := new(Thread)
+	err := c.cc.Invoke(ctx, ForumService_Vote_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetPost(ctx context.Context, in *GetPostRequest, opts ...grpc.CallOption) (*PostFull, error) {
+	out := new(PostFull)
+	err := c.cc.Invoke(ctx, ForumService_GetPost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThreadPosts(ctx context.Context, in *GetThreadPostsRequest, opts ...grpc.CallOption) (ForumService_GetThreadPostsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ForumService_ServiceDesc.Streams[0], ForumService_GetThreadPosts_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &forumServiceGetThreadPostsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ForumService_GetThreadPostsClient interface {
+	Recv() (*Post, error)
+	grpc.ClientStream
+}
+
+type forumServiceGetThreadPostsClient struct {
+	grpc.ClientStream
+}
+
+func (x *forumServiceGetThreadPostsClient) Recv() (*Post, error) {
+	m := new(Post)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *forumServiceClient) UpdatePost(ctx context.Context, in *UpdatePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	out := new(Post)
+	err := c.cc.Invoke(ctx, ForumService_UpdatePost_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThreadsPage(ctx context.Context, in *GetThreadsPageRequest, opts ...grpc.CallOption) (*ThreadPage, error) {
+	out := new(ThreadPage)
+	err := c.cc.Invoke(ctx, ForumService_GetThreadsPage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThreadPostsPage(ctx context.Context, in *GetThreadPostsPageRequest, opts ...grpc.CallOption) (*PostPage, error) {
+	out := new(PostPage)
+	err := c.cc.Invoke(ctx, ForumService_GetThreadPostsPage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetUsersPage(ctx context.Context, in *GetUsersPageRequest, opts ...grpc.CallOption) (*UserPage, error) {
+	out := new(UserPage)
+	err := c.cc.Invoke(ctx, ForumService_GetUsersPage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetUsersByNicknames(ctx context.Context, in *GetUsersByNicknamesRequest, opts ...grpc.CallOption) (*UserList, error) {
+	out := new(UserList)
+	err := c.cc.Invoke(ctx, ForumService_GetUsersByNicknames_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetForumsBySlugs(ctx context.Context, in *GetForumsBySlugsRequest, opts ...grpc.CallOption) (*ForumList, error) {
+	out := new(ForumList)
+	err := c.cc.Invoke(ctx, ForumService_GetForumsBySlugs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetThreadsByIds(ctx context.Context, in *GetThreadsByIdsRequest, opts ...grpc.CallOption) (*ThreadList, error) {
+	out := new(ThreadList)
+	err := c.cc.Invoke(ctx, ForumService_GetThreadsByIds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, ForumService_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *forumServiceClient) Clear(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ForumService_Clear_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ForumServiceServer is the server API for ForumService service.
+// All implementations must embed UnimplementedForumServiceServer
+// for forward compatibility
+type ForumServiceServer interface {
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	CreateUser(context.Context, *User) (*User, error)
+	// version, when set, must match the current one, like If-Match.
+	UpdateUser(context.Context, *User) (*User, error)
+	GetUsers(context.Context, *GetUsersRequest) (*UserList, error)
+	CreateForum(context.Context, *Forum) (*Forum, error)
+	GetForum(context.Context, *GetForumRequest) (*Forum, error)
+	CreateThread(context.Context, *Thread) (*Thread, error)
+	UpdateThread(context.Context, *UpdateThreadRequest) (*Thread, error)
+	GetThreads(context.Context, *GetThreadsRequest) (*ThreadList, error)
+	GetThread(context.Context, *GetThreadRequest) (*Thread, error)
+	CreatePosts(context.Context, *CreatePostsRequest) (*PostList, error)
+	// Vote returns the thread with the updated vote count.
+	Vote(context.Context, *VoteRequest) (*Thread, error)
+	GetPost(context.Context, *GetPostRequest) (*PostFull, error)
+	// GetThreadPosts sends the posts as they are read from the database.
+	GetThreadPosts(*GetThreadPostsRequest, ForumService_GetThreadPostsServer) error
+	UpdatePost(context.Context, *UpdatePostRequest) (*Post, error)
+	GetThreadsPage(context.Context, *GetThreadsPageRequest) (*ThreadPage, error)
+	GetThreadPostsPage(context.Context, *GetThreadPostsPageRequest) (*PostPage, error)
+	GetUsersPage(context.Context, *GetUsersPageRequest) (*UserPage, error)
+	GetUsersByNicknames(context.Context, *GetUsersByNicknamesRequest) (*UserList, error)
+	GetForumsBySlugs(context.Context, *GetForumsBySlugsRequest) (*ForumList, error)
+	GetThreadsByIds(context.Context, *GetThreadsByIdsRequest) (*ThreadList, error)
+	GetStatus(context.Context, *emptypb.Empty) (*Status, error)
+	Clear(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+	mustEmbedUnimplementedForumServiceServer()
+}
+
+// UnimplementedForumServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedForumServiceServer struct {
+}
+
+func (UnimplementedForumServiceServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedForumServiceServer) CreateUser(context.Context, *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedForumServiceServer) UpdateUser(context.Context, *User) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedForumServiceServer) GetUsers(context.Context, *GetUsersRequest) (*UserList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsers not implemented")
+}
+func (UnimplementedForumServiceServer) CreateForum(context.Context, *Forum) (*Forum, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateForum not implemented")
+}
+func (UnimplementedForumServiceServer) GetForum(context.Context, *GetForumRequest) (*Forum, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForum not implemented")
+}
+func (UnimplementedForumServiceServer) CreateThread(context.Context, *Thread) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateThread not implemented")
+}
+func (UnimplementedForumServiceServer) UpdateThread(context.Context, *UpdateThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateThread not implemented")
+}
+func (UnimplementedForumServiceServer) GetThreads(context.Context, *GetThreadsRequest) (*ThreadList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThreads not implemented")
+}
+func (UnimplementedForumServiceServer) GetThread(context.Context, *GetThreadRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThread not implemented")
+}
+func (UnimplementedForumServiceServer) CreatePosts(context.Context, *CreatePostsRequest) (*PostList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePosts not implemented")
+}
+func (UnimplementedForumServiceServer) Vote(context.Context, *VoteRequest) (*Thread, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Vote not implemented")
+}
+func (UnimplementedForumServiceServer) GetPost(context.Context, *GetPostRequest) (*PostFull, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPost not implemented")
+}
+func (UnimplementedForumServiceServer) GetThreadPosts(*GetThreadPostsRequest, ForumService_GetThreadPostsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetThreadPosts not implemented")
+}
+func (UnimplementedForumServiceServer) UpdatePost(context.Context, *UpdatePostRequest) (*Post, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePost not implemented")
+}
+func (UnimplementedForumServiceServer) GetThreadsPage(context.Context, *GetThreadsPageRequest) (*ThreadPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThreadsPage not implemented")
+}
+func (UnimplementedForumServiceServer) GetThreadPostsPage(context.Context, *GetThreadPostsPageRequest) (*PostPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThreadPostsPage not implemented")
+}
+func (UnimplementedForumServiceServer) GetUsersPage(context.Context, *GetUsersPageRequest) (*UserPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsersPage not implemented")
+}
+func (UnimplementedForumServiceServer) GetUsersByNicknames(context.Context, *GetUsersByNicknamesRequest) (*UserList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsersByNicknames not implemented")
+}
+func (UnimplementedForumServiceServer) GetForumsBySlugs(context.Context, *GetForumsBySlugsRequest) (*ForumList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetForumsBySlugs not implemented")
+}
+func (UnimplementedForumServiceServer) GetThreadsByIds(context.Context, *GetThreadsByIdsRequest) (*ThreadList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThreadsByIds not implemented")
+}
+func (UnimplementedForumServiceServer) GetStatus(context.Context, *emptypb.Empty) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedForumServiceServer) Clear(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clear not implemented")
+}
+func (UnimplementedForumServiceServer) mustEmbedUnimplementedForumServiceServer() {}
+
+// UnsafeForumServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ForumServiceServer will
+// result in compilation errors.
+type UnsafeForumServiceServer interface {
+	mustEmbedUnimplementedForumServiceServer()
+}
+
+func RegisterForumServiceServer(s grpc.ServiceRegistrar, srv ForumServiceServer) {
+	s.RegisterService(&ForumService_ServiceDesc, srv)
+}
+
+func _ForumService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_CreateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).CreateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(User)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_UpdateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).UpdateUser(ctx, req.(*User))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetUsers(ctx, req.(*GetUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_CreateForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Forum)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).CreateForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_CreateForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).CreateForum(ctx, req.(*Forum))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetForum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetForumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetForum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetForum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetForum(ctx, req.(*GetForumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_CreateThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Thread)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).CreateThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_CreateThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).CreateThread(ctx, req.(*Thread))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_UpdateThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).UpdateThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_UpdateThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).UpdateThread(ctx, req.(*UpdateThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThreads_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetThreads(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetThreads_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetThreads(ctx, req.(*GetThreadsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThread_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetThread(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetThread_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetThread(ctx, req.(*GetThreadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_CreatePosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).CreatePosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_CreatePosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).CreatePosts(ctx, req.(*CreatePostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_Vote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).Vote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_Vote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).Vote(ctx, req.(*VoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetPost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetPost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetPost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetPost(ctx, req.(*GetPostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThreadPosts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetThreadPostsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ForumServiceServer).GetThreadPosts(m, &forumServiceGetThreadPostsServer{stream})
+}
+
+type ForumService_GetThreadPostsServer interface {
+	Send(*Post) error
+	grpc.ServerStream
+}
+
+type forumServiceGetThreadPostsServer struct {
+	grpc.ServerStream
+}
+
+func (x *forumServiceGetThreadPostsServer) Send(m *Post) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ForumService_UpdatePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).UpdatePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_UpdatePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).UpdatePost(ctx, req.(*UpdatePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThreadsPage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadsPageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetThreadsPage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetThreadsPage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetThreadsPage(ctx, req.(*GetThreadsPageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThreadPostsPage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadPostsPageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetThreadPostsPage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetThreadPostsPage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetThreadPostsPage(ctx, req.(*GetThreadPostsPageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetUsersPage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsersPageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetUsersPage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetUsersPage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetUsersPage(ctx, req.(*GetUsersPageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetUsersByNicknames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsersByNicknamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetUsersByNicknames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetUsersByNicknames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetUsersByNicknames(ctx, req.(*GetUsersByNicknamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetForumsBySlugs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetForumsBySlugsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetForumsBySlugs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetForumsBySlugs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetForumsBySlugs(ctx, req.(*GetForumsBySlugsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetThreadsByIds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThreadsByIdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetThreadsByIds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetThreadsByIds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetThreadsByIds(ctx, req.(*GetThreadsByIdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).GetStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ForumService_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ForumServiceServer).Clear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ForumService_Clear_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ForumServiceServer).Clear(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ForumService_ServiceDesc is the grpc.ServiceDesc for ForumService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ForumService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "forum.v1.ForumService",
+	HandlerType: (*ForumServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _ForumService_GetUser_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _ForumService_CreateUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _ForumService_UpdateUser_Handler,
+		},
+		{
+			MethodName: "GetUsers",
+			Handler:    _ForumService_GetUsers_Handler,
+		},
+		{
+			MethodName: "CreateForum",
+			Handler:    _ForumService_CreateForum_Handler,
+		},
+		{
+			MethodName: "GetForum",
+			Handler:    _ForumService_GetForum_Handler,
+		},
+		{
+			MethodName: "CreateThread",
+			Handler:    _ForumService_CreateThread_Handler,
+		},
+		{
+			MethodName: "UpdateThread",
+			Handler:    _ForumService_UpdateThread_Handler,
+		},
+		{
+			MethodName: "GetThreads",
+			Handler:    _ForumService_GetThreads_Handler,
+		},
+		{
+			MethodName: "GetThread",
+			Handler:    _ForumService_GetThread_Handler,
+		},
+		{
+			MethodName: "CreatePosts",
+			Handler:    _ForumService_CreatePosts_Handler,
+		},
+		{
+			MethodName: "Vote",
+			Handler:    _ForumService_Vote_Handler,
+		},
+		{
+			MethodName: "GetPost",
+			Handler:    _ForumService_GetPost_Handler,
+		},
+		{
+			MethodName: "UpdatePost",
+			Handler:    _ForumService_UpdatePost_Handler,
+		},
+		{
+			MethodName: "GetThreadsPage",
+			Handler:    _ForumService_GetThreadsPage_Handler,
+		},
+		{
+			MethodName: "GetThreadPostsPage",
+			Handler:    _ForumService_GetThreadPostsPage_Handler,
+		},
+		{
+			MethodName: "GetUsersPage",
+			Handler:    _ForumService_GetUsersPage_Handler,
+		},
+		{
+			MethodName: "GetUsersByNicknames",
+			Handler:    _ForumService_GetUsersByNicknames_Handler,
+		},
+		{
+			MethodName: "GetForumsBySlugs",
+			Handler:    _ForumService_GetForumsBySlugs_Handler,
+		},
+		{
+			MethodName: "GetThreadsByIds",
+			Handler:    _ForumService_GetThreadsByIds_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _ForumService_GetStatus_Handler,
+		},
+		{
+			MethodName: "Clear",
+			Handler:    _ForumService_Clear_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetThreadPosts",
+			Handler:       _ForumService_GetThreadPosts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "forum/v1/forum.proto",
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+)
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	nickname := mux.Vars(r)["nickname"]
+	key := models.APIKey{}
+	if err := utils.Decode(r, &key); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
+		return
+	}
+
+	result, err := h.uc.CreateAPIKey(r.Context(), nickname, key)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.Response(w, r, http.StatusCreated, result)
+}
+
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	nickname := mux.Vars(r)["nickname"]
+	result, err := h.uc.GetAPIKeys(r.Context(), nickname)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.uc.RevokeAPIKey(r.Context(), vars["nickname"], vars["id"]); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceAPIKey, vars["id"])
+		return
+	}
+	utils.Response(w, r, http.StatusNoContent, nil)
+}
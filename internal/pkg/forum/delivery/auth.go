@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+)
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	credentials := models.Credentials{}
+	if err := utils.Decode(r, &credentials); err != nil {
+		utils.ErrorResponse(w, r, err, "", "")
+		return
+	}
+
+	result, err := h.uc.Login(r.Context(), credentials)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, credentials.Nickname)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	request := models.RefreshRequest{}
+	if err := utils.Decode(r, &request); err != nil {
+		utils.ErrorResponse(w, r, err, "", "")
+		return
+	}
+
+	result, err := h.uc.Refresh(r.Context(), request)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, "", "")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Logout(r.Context()); err != nil {
+		utils.ErrorResponse(w, r, err, "", "")
+		return
+	}
+	utils.Response(w, r, http.StatusNoContent, nil)
+}
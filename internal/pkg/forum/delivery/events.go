@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/events"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventsWriteWait bounds a single write to an event stream. A client that
+// stops reading is disconnected by it, or by the broker once its buffer
+// fills up, whichever comes first.
+const eventsWriteWait = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		code := models.CodeBadRequest
+		if status == http.StatusForbidden {
+			code = models.CodeForbidden
+		}
+		utils.Response(w, r, status, models.Error{Code: code, Message: reason.Error()})
+	},
+}
+
+// IsEventStream reports whether r opens one of the thread event streams,
+// which stay open for as long as the client listens.
+func IsEventStream(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/thread/") &&
+		(strings.HasSuffix(r.URL.Path, "/events") || strings.HasSuffix(r.URL.Path, "/events/ws"))
+}
+
+// ThreadEvents streams the changes of a thread as Server-Sent Events. A
+// reconnecting EventSource resumes with the Last-Event-ID header; other
+// clients can pass last_event_id in the query instead.
+func (h *Handler) ThreadEvents(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	sub, ok := h.subscribe(w, r, lastEventID)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	write := func(format string, args ...interface{}) error {
+		// The server's write timeout is meant for ordinary responses; each
+		// write gets its own deadline instead.
+		_ = rc.SetWriteDeadline(time.Now().Add(eventsWriteWait))
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := write(": subscribed\n\n"); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(h.cfg.Events.KeepAlive.Std())
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Lagged or shutting down: the client reconnects and resumes.
+				return
+			}
+			err = write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		case <-keepAlive.C:
+			err = write(": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wsEvent is the WebSocket message of an event; id is a string as in SSE so
+// clients need not worry about integer precision.
+type wsEvent struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// ThreadEventsWS streams the same events as ThreadEvents over a WebSocket,
+// one JSON text message per event. Messages from the client are ignored. A
+// subscriber that falls behind is closed with 1013 (try again later) and
+// should reconnect with the id of the last event it handled in
+// last_event_id.
+func (h *Handler) ThreadEventsWS(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.subscribe(w, r, r.URL.Query().Get("last_event_id"))
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has responded already.
+		return
+	}
+	defer conn.Close()
+
+	keepAlive := h.cfg.Events.KeepAlive.Std()
+	conn.SetReadLimit(4096)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * keepAlive))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * keepAlive))
+	})
+	// Reading is what processes pongs and the client's close; the loop ends
+	// when the connection does.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				code, reason := websocket.CloseGoingAway, "server shutting down"
+				if errors.Is(sub.Err(), events.ErrLagged) {
+					code, reason = websocket.CloseTryAgainLater, "slow consumer"
+				}
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(eventsWriteWait))
+				return
+			}
+			message, _ := json.Marshal(wsEvent{ID: strconv.FormatUint(event.ID, 10), Event: event.Type, Data: event.Data})
+			_ = conn.SetWriteDeadline(time.Now().Add(eventsWriteWait))
+			err = conn.WriteMessage(websocket.TextMessage, message)
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventsWriteWait))
+		case <-gone:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// subscribe resolves the thread of the request and subscribes to it,
+// responding with the error if either fails.
+func (h *Handler) subscribe(w http.ResponseWriter, r *http.Request, lastEventID string) (*events.Subscription, bool) {
+	slugOrId := mux.Vars(r)["slug_or_id"]
+	thread, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return nil, false
+	}
+	sub, err := h.uc.SubscribeThread(thread.ID, lastEventID)
+	if err != nil {
+		if errors.Is(err, models.ErrorUnavailable) {
+			w.Header().Set("Retry-After", "1")
+		}
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return nil, false
+	}
+	return sub, true
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/events"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// threadRepo knows a single thread.
+type threadRepo struct {
+	forum.ForumRepository
+	thread models.Thread
+}
+
+func (r *threadRepo) GetThreadById(ctx context.Context, id int) (models.Thread, error) {
+	if id != r.thread.ID {
+		return models.Thread{}, models.ErrorNotFound
+	}
+	return r.thread, nil
+}
+
+func TestThreadEventsWhileDraining(t *testing.T) {
+	cfg := config.Default()
+	broker := events.NewBroker(cfg.Events.History, cfg.Events.Buffer, cfg.Events.Retention.Std())
+	broker.Close()
+	repo := &threadRepo{thread: models.Thread{ID: 42, Slug: "jones", Created: time.Now()}}
+	h := NewForumHandler(usecase.NewForumUsecase(repo, cfg, broker, nil), cfg)
+
+	cases := []struct {
+		name   string
+		serve  http.HandlerFunc
+		target string
+		header http.Header
+	}{
+		{"server-sent events", h.ThreadEvents, "/api/thread/42/events", http.Header{"Accept": {"text/event-stream"}}},
+		{"websocket", h.ThreadEventsWS, "/api/thread/42/events/ws", http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.Header = tc.header
+			w := httptest.NewRecorder()
+			tc.serve(w, mux.SetURLVars(r, map[string]string{"slug_or_id": "42"}))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("got %d, want 503", w.Code)
+			}
+			if got := w.Header().Get("Retry-After"); got == "" {
+				t.Error("no Retry-After")
+			}
+			var body models.Error
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != models.CodeUnavailable {
+				t.Errorf("error code %q, want %q", body.Code, models.CodeUnavailable)
+			}
+		})
+	}
+}
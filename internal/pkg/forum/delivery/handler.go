@@ -2,23 +2,28 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
-	"github.com/mailru/easyjson"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Handler struct {
-	uc forum.ForumUsecase
+	uc  forum.ForumUsecase
+	cfg *config.Config
 }
 
-func NewForumHandler(forumUsecase forum.ForumUsecase) *Handler {
+func NewForumHandler(forumUsecase forum.ForumUsecase, cfg *config.Config) *Handler {
 	return &Handler{
-		uc: forumUsecase,
+		uc:  forumUsecase,
+		cfg: cfg,
 	}
 }
 
@@ -26,16 +31,16 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, ok := vars["nickname"]
 	if !ok {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	userOut, err := h.uc.GetUser(r.Context(), nickname)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, nickname)
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
-	utils.Response(w, http.StatusOK, userOut)
+	utils.ConditionalResponse(w, r, userOut, utils.VersionETag(userOut.Version), time.Time{})
 	return
 }
 
@@ -43,95 +48,93 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, ok := vars["nickname"]
 	if !ok {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	user := models.User{}
-	err := easyjson.UnmarshalFromReader(r.Body, &user)
+	err := utils.Decode(r, &user)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
 	user.Nickname = nickname
 
 	result, err := h.uc.CreateUser(r.Context(), user)
 	if errors.Is(err, models.ErrorConflict) {
-		utils.Response(w, http.StatusConflict, result)
+		utils.Response(w, r, http.StatusConflict, result)
 		return
-	} else if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
+	} else if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
 
-	utils.Response(w, http.StatusCreated, result[0])
+	utils.Response(w, r, http.StatusCreated, result[0])
 }
 
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, found := vars["nickname"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	user := models.User{}
-	err := easyjson.UnmarshalFromReader(r.Body, &user)
+	err := utils.Decode(r, &user)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
 	user.Nickname = nickname
-
-	finalUser, err := h.uc.UpdateUser(r.Context(), user)
-	if errors.Is(err, models.ErrorConflict) {
-		utils.Response(w, http.StatusConflict, models.Error{Message: "Can't update data"})
+	if user.Version, err = utils.IfMatchVersion(r); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, models.Error{Message: "User doesn't exists"})
+
+	finalUser, err := h.uc.UpdateUser(r.Context(), user)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, nickname)
 		return
 	}
-	utils.Response(w, http.StatusOK, finalUser)
+	w.Header().Set("ETag", utils.VersionETag(finalUser.Version))
+	utils.Response(w, r, http.StatusOK, finalUser)
 }
 
 func (h *Handler) CreateForum(w http.ResponseWriter, r *http.Request) {
 	forumInfo := models.Forum{}
-	err := easyjson.UnmarshalFromReader(r.Body, &forumInfo)
+	err := utils.Decode(r, &forumInfo)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, "")
 		return
 	}
 
 	result, err := h.uc.CreateForum(r.Context(), forumInfo)
 	if errors.Is(err, models.ErrorConflict) {
-		utils.Response(w, http.StatusConflict, result)
-		return
-	} else if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Forum owner is not found")
+		utils.Response(w, r, http.StatusConflict, result)
 		return
-	} else if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
+	} else if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, forumInfo.User)
 		return
 	}
 
-	utils.Response(w, http.StatusCreated, result)
+	utils.Response(w, r, http.StatusCreated, result)
 }
 
 func (h *Handler) GetForumDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	nickname, ok := vars["slug"]
+	slug, ok := vars["slug"]
 	if !ok {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
-	userOut, err := h.uc.GetForum(r.Context(), nickname)
+	forumOut, err := h.uc.GetForum(r.Context(), slug)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, nickname)
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
 		return
 	}
-	utils.Response(w, http.StatusOK, userOut)
+	utils.ConditionalResponse(w, r, forumOut, "", time.Time{})
 	return
 }
 
@@ -139,31 +142,33 @@ func (h *Handler) CreateThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, ok := vars["slug"]
 	if !ok {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	thread := models.Thread{}
-	err := easyjson.UnmarshalFromReader(r.Body, &thread)
+	err := utils.Decode(r, &thread)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, "")
 		return
 	}
 	thread.Forum = slug
 
 	result, err := h.uc.CreateThread(r.Context(), thread)
 	if errors.Is(err, models.ErrorConflict) {
-		utils.Response(w, http.StatusConflict, result)
+		utils.Response(w, r, http.StatusConflict, result)
 		return
-	} else if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
-		return
-	} else if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Slug owner is not found")
+	} else if err != nil {
+		// The repository does not tell a missing author from a missing forum.
+		status, body := utils.NewError(err, utils.ResourceForum, slug)
+		if status == http.StatusNotFound {
+			body.Message = fmt.Sprintf("Can't find forum %q or user %q", slug, thread.Author)
+		}
+		utils.Response(w, r, status, body)
 		return
 	}
 
-	utils.Response(w, http.StatusCreated, result)
+	utils.Response(w, r, http.StatusCreated, result)
 }
 
 func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
@@ -171,7 +176,7 @@ func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, found := vars["slug"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 	query := r.URL.Query()
@@ -186,12 +191,12 @@ func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.uc.GetThreads(r.Context(), slug, limit, since, desc)
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Forum not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	utils.Response(w, r, http.StatusOK, result)
 
 }
 
@@ -199,41 +204,45 @@ func (h *Handler) CreatePosts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	thread, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
-	if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
-		return
-	} else if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Thread not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
 	posts := models.PostsList{}
-	err = easyjson.UnmarshalFromReader(r.Body, &posts)
+	err = utils.Decode(r, &posts)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, "")
 		return
 	}
 
 	if len(posts) == 0 {
-		utils.Response(w, http.StatusCreated, posts)
+		utils.Response(w, r, http.StatusCreated, posts)
 		return
 	}
 
 	createdPosts, err := h.uc.CreatePosts(r.Context(), posts, thread)
 	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Can't find post author")
+		status, body := utils.NewError(err, utils.ResourceUser, "")
+		body.Message = "Can't find post author"
+		utils.Response(w, r, status, body)
 		return
 	} else if errors.Is(err, models.ErrorConflict) {
-		utils.Response(w, http.StatusConflict, models.Error{Message: "Wrong post parent"})
+		status, body := utils.NewError(err, utils.ResourcePost, "")
+		body.Message = "Parent post was created in another thread"
+		utils.Response(w, r, status, body)
+		return
+	} else if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, "")
 		return
 	}
 
-	utils.Response(w, http.StatusCreated, createdPosts)
+	utils.Response(w, r, http.StatusCreated, createdPosts)
 
 }
 
@@ -241,23 +250,20 @@ func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	thread, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
-	if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
-		return
-	} else if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Thread not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
 	vote := models.Vote{}
-	err = easyjson.UnmarshalFromReader(r.Body, &vote)
+	err = utils.Decode(r, &vote)
 	if err != nil {
-		utils.Response(w, http.StatusInternalServerError, nil)
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
@@ -265,37 +271,37 @@ func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
 		vote.Thread = thread.ID
 	}
 
-	if err = h.uc.Vote(r.Context(), vote); errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "User not found")
+	if err = h.uc.Vote(r.Context(), vote); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceUser, vote.Nickname)
 		return
 	}
 
 	threadUpdated, _ := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
-	utils.Response(w, http.StatusOK, threadUpdated)
+	utils.Response(w, r, http.StatusOK, threadUpdated)
 }
 
 func (h *Handler) GetThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
 	result, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Thread not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	utils.ConditionalResponse(w, r, result, utils.VersionETag(result.Version), result.LastModified())
 }
 
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, found := vars["id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Response(w, r, http.StatusNotFound, nil)
 		return
 	}
 
@@ -306,12 +312,17 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.uc.GetPost(r.Context(), id, related)
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Post not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, id)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	// Related objects change independently of the post version.
+	etag := ""
+	if len(related) == 0 {
+		etag = utils.VersionETag(result.Post.Version)
+	}
+	utils.ConditionalResponse(w, r, result, etag, result.LastModified())
 }
 
 func (h *Handler) GetThreadPosts(w http.ResponseWriter, r *http.Request) {
@@ -335,21 +346,38 @@ func (h *Handler) GetThreadPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	thread, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Thread not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
-	result, _ := h.uc.GetThreadPosts(r.Context(), limit, since, desc, sort, thread.ID)
-
-	utils.Response(w, http.StatusOK, result)
+	stream := utils.NewArrayStream(w, r)
+	err = h.uc.StreamThreadPosts(r.Context(), limit, since, desc, sort, thread.ID, stream.Counter(), func(post models.Post) error {
+		return stream.Write(post)
+	})
+	if err != nil {
+		if !stream.Started() {
+			utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+			return
+		}
+		// The status is out already. Abort the connection so the client
+		// sees a truncated response instead of a short but valid list.
+		if r.Context().Err() == nil {
+			log.Printf("stream posts of thread %d: %v", thread.ID, err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+	stream.Close()
 }
 
 func (h *Handler) UpdateThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, _ := vars["slug_or_id"]
 	thread := models.Thread{}
-	easyjson.UnmarshalFromReader(r.Body, &thread)
+	if err := utils.Decode(r, &thread); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return
+	}
 
 	idInt, err := strconv.Atoi(slugOrId)
 	if err != nil {
@@ -357,15 +385,19 @@ func (h *Handler) UpdateThread(w http.ResponseWriter, r *http.Request) {
 	} else {
 		thread.ID = idInt
 	}
+	if thread.Version, err = utils.IfMatchVersion(r); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return
+	}
 
 	result, err := h.uc.UpdateThread(r.Context(), thread)
-
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Thread not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	w.Header().Set("ETag", utils.VersionETag(result.Version))
+	utils.Response(w, r, http.StatusOK, result)
 }
 
 func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
@@ -385,15 +417,12 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result, err := h.uc.GetUsers(r.Context(), slug, limit, since, desc)
-	if errors.Is(err, models.ErrorInternal) {
-		utils.Response(w, http.StatusInternalServerError, nil)
-		return
-	} else if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Forum not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	utils.Response(w, r, http.StatusOK, result)
 
 }
 
@@ -402,22 +431,39 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	idStr, _ := vars["id"]
 	id, _ := strconv.Atoi(idStr)
 	postUpdateInfo := models.PostUpdate{ID: id}
-	easyjson.UnmarshalFromReader(r.Body, &postUpdateInfo)
+	if err := utils.Decode(r, &postUpdateInfo); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, idStr)
+		return
+	}
+	version, err := utils.IfMatchVersion(r)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, idStr)
+		return
+	}
+	postUpdateInfo.Version = version
 
 	result, err := h.uc.UpdatePost(r.Context(), postUpdateInfo)
-	if errors.Is(err, models.ErrorNotFound) {
-		utils.Response(w, http.StatusNotFound, "Post not found")
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourcePost, idStr)
 		return
 	}
 
-	utils.Response(w, http.StatusOK, result)
+	w.Header().Set("ETag", utils.VersionETag(result.Version))
+	utils.Response(w, r, http.StatusOK, result)
 }
 
 func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
-	utils.Response(w, http.StatusOK, h.uc.GetStatus())
+	utils.Response(w, r, http.StatusOK, h.uc.GetStatus())
 }
 
 func (h *Handler) Clear(w http.ResponseWriter, r *http.Request) {
-	h.uc.Clear()
-	utils.Response(w, http.StatusOK, nil)
+	if !h.cfg.Features.AllowClear {
+		utils.Response(w, r, http.StatusForbidden, models.Error{Code: models.CodeForbidden, Message: "Clear is disabled"})
+		return
+	}
+	if err := h.uc.Clear(r.Context()); err != nil {
+		utils.ErrorResponse(w, r, err, "", "")
+		return
+	}
+	utils.Response(w, r, http.StatusOK, nil)
 }
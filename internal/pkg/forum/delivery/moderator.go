@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+)
+
+func (h *Handler) GetModerators(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	result, err := h.uc.GetModerators(r.Context(), slug)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) AddModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.uc.AddModerator(r.Context(), vars["slug"], vars["nickname"]); err != nil {
+		moderatorError(w, r, err, "Can't find forum %q or user %q")
+		return
+	}
+	utils.Response(w, r, http.StatusNoContent, nil)
+}
+
+func (h *Handler) RemoveModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.uc.RemoveModerator(r.Context(), vars["slug"], vars["nickname"]); err != nil {
+		moderatorError(w, r, err, "Can't find forum %q or its moderator %q")
+		return
+	}
+	utils.Response(w, r, http.StatusNoContent, nil)
+}
+
+// moderatorError reports a failed moderator change; the usecase does not
+// tell a missing forum from a missing user, so notFound names both.
+func moderatorError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	vars := mux.Vars(r)
+	if !errors.Is(err, models.ErrorNotFound) {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, vars["slug"])
+		return
+	}
+	status, body := utils.NewError(err, utils.ResourceForum, vars["slug"])
+	body.Message = fmt.Sprintf(notFound, vars["slug"], vars["nickname"])
+	utils.Response(w, r, status, body)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+)
+
+// The /api/v2 list handlers take limit, desc and an opaque cursor instead of
+// since, and answer with {items, next_cursor, prev_cursor}.
+
+func (h *Handler) GetThreadsPage(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	query := r.URL.Query()
+
+	result, err := h.uc.GetThreadsPage(r.Context(), slug, query.Get("limit"), query.Get("cursor"), query.Get("desc"))
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) GetThreadPostsPage(w http.ResponseWriter, r *http.Request) {
+	slugOrId := mux.Vars(r)["slug_or_id"]
+	query := r.URL.Query()
+
+	thread, err := h.uc.CheckThreadByIdOrSlug(r.Context(), slugOrId)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return
+	}
+
+	result, err := h.uc.GetThreadPostsPage(r.Context(), query.Get("limit"), query.Get("cursor"), query.Get("desc"), query.Get("sort"), thread.ID)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceThread, slugOrId)
+		return
+	}
+
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) GetUsersPage(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	query := r.URL.Query()
+
+	result, err := h.uc.GetUsersPage(r.Context(), slug, query.Get("limit"), query.Get("cursor"), query.Get("desc"))
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+
+	utils.Response(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	webhook := models.Webhook{Active: true}
+	if err := utils.Decode(r, &webhook); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+	webhook.Forum = slug
+
+	result, err := h.uc.CreateWebhook(r.Context(), webhook)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+	utils.Response(w, r, http.StatusCreated, result)
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	result, err := h.uc.GetWebhooks(r.Context(), slug)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceForum, slug)
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	result, err := h.uc.GetWebhook(r.Context(), vars["slug"], vars["id"])
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceWebhook, vars["id"])
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	update := models.WebhookUpdate{}
+	if err := utils.Decode(r, &update); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceWebhook, vars["id"])
+		return
+	}
+	update.Forum = vars["slug"]
+	update.ID, _ = strconv.Atoi(vars["id"])
+
+	result, err := h.uc.UpdateWebhook(r.Context(), update)
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceWebhook, vars["id"])
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.uc.DeleteWebhook(r.Context(), vars["slug"], vars["id"]); err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceWebhook, vars["id"])
+		return
+	}
+	utils.Response(w, r, http.StatusNoContent, nil)
+}
+
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	result, err := h.uc.GetWebhookDeliveries(r.Context(), vars["slug"], vars["id"],
+		query.Get("limit"), query.Get("since"), query.Get("status"), query.Get("desc"))
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceWebhook, vars["id"])
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
+
+func (h *Handler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	result, err := h.uc.RetryWebhookDelivery(r.Context(), vars["slug"], vars["id"], vars["delivery"])
+	if err != nil {
+		utils.ErrorResponse(w, r, err, utils.ResourceDelivery, vars["delivery"])
+		return
+	}
+	utils.Response(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+const codeTooComplex = "query_too_complex"
+
+// budget is the complexity allowance of one query. graphql-go has no hook
+// to price a query before running it, so the resolvers charge the budget
+// before each lookup: 1 for an object and the limit for a list. Once the
+// budget is spent every further charge fails, which stops the query from
+// reaching the database again.
+type budget struct {
+	max  int64
+	left int64
+}
+
+type budgetKey struct{}
+
+func withBudget(ctx context.Context, max int) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budget{max: int64(max), left: int64(max)})
+}
+
+func charge(ctx context.Context, cost int) error {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return nil
+	}
+	if atomic.AddInt64(&b.left, -int64(cost)) < 0 {
+		return &tooComplexError{max: b.max}
+	}
+	return nil
+}
+
+type tooComplexError struct {
+	max int64
+}
+
+func (e *tooComplexError) Error() string {
+	return fmt.Sprintf("Query exceeds the complexity limit of %d; ask for fewer or smaller lists", e.max)
+}
+
+func (e *tooComplexError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": codeTooComplex}
+}
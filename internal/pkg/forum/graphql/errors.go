@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"log"
+	"net/http"
+)
+
+// fieldError is a resolver error in the shape of the REST one: the message
+// becomes the GraphQL error message and the code, the HTTP status the REST
+// API would answer with and the field details go to its extensions.
+type fieldError struct {
+	status int
+	body   models.Error
+}
+
+func resolverError(err error, resource, id string) *fieldError {
+	status, body := utils.NewError(err, resource, id)
+	if status == http.StatusInternalServerError {
+		log.Printf("graphql %s %q: %v", resource, id, err)
+	}
+	return &fieldError{status: status, body: body}
+}
+
+func (e *fieldError) Error() string {
+	return e.body.Message
+}
+
+func (e *fieldError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{
+		"code":   e.body.Code,
+		"status": e.status,
+	}
+	if e.body.Resource != "" {
+		extensions["resource"] = e.body.Resource
+	}
+	if len(e.body.Details) > 0 {
+		extensions["details"] = e.body.Details
+	}
+	return extensions
+}
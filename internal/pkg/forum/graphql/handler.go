@@ -0,0 +1,147 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"log"
+	"net/http"
+	"strings"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// Handler serves GraphQL over HTTP POST on top of ForumUsecase.
+type Handler struct {
+	schema *graphqlgo.Schema
+	uc     forum.ForumUsecase
+	cfg    *config.Config
+}
+
+func NewHandler(uc forum.ForumUsecase, cfg *config.Config) (*Handler, error) {
+	opts := []graphqlgo.SchemaOpt{
+		graphqlgo.UseStringDescriptions(),
+		graphqlgo.MaxDepth(cfg.GraphQL.MaxDepth),
+	}
+	if !cfg.GraphQL.Introspection {
+		opts = append(opts, graphqlgo.DisableIntrospection())
+	}
+	schema, err := graphqlgo.ParseSchema(schemaSource, &resolver{uc: uc, cfg: cfg}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("parse GraphQL schema: %w", err)
+	}
+	return &Handler{schema: schema, uc: uc, cfg: cfg}, nil
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := utils.Decode(r, &req); err != nil {
+		status, body := utils.NewError(err, "", "")
+		h.respond(w, status, requestError(body))
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		h.respond(w, http.StatusBadRequest, requestError(models.Error{Code: models.CodeBadRequest, Message: "query is required"}))
+		return
+	}
+	if len(req.Query) > h.cfg.GraphQL.MaxQueryLength {
+		message := fmt.Sprintf("query is longer than %d bytes", h.cfg.GraphQL.MaxQueryLength)
+		h.respond(w, http.StatusBadRequest, requestError(models.Error{Code: codeTooComplex, Message: message}))
+		return
+	}
+
+	ctx := withBudget(r.Context(), h.cfg.GraphQL.MaxComplexity)
+	ctx = context.WithValue(ctx, loadersKey{}, h.newLoaders(ctx))
+	h.respond(w, http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// respond always answers in JSON: GraphQL responses have no MessagePack or
+// Protobuf representation.
+func (h *Handler) respond(w http.ResponseWriter, status int, response *graphqlgo.Response) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("encode GraphQL response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", utils.ContentTypeJSON)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func requestError(body models.Error) *graphqlgo.Response {
+	err := &gqlerrors.QueryError{
+		Message:    body.Message,
+		Extensions: map[string]interface{}{"code": body.Code},
+	}
+	if len(body.Details) > 0 {
+		err.Extensions["details"] = body.Details
+	}
+	return &graphqlgo.Response{Errors: []*gqlerrors.QueryError{err}}
+}
+
+func (h *Handler) newLoaders(ctx context.Context) *loaders {
+	return &loaders{
+		users: newLoader(ctx, func(ctx context.Context, nicknames []string) (map[string]models.User, error) {
+			users, err := h.uc.GetUsersByNicknames(ctx, nicknames)
+			if err != nil {
+				return nil, err
+			}
+			// Nicknames compare case-insensitively, so the rows are matched
+			// back to the keys the same way.
+			byNickname := make(map[string]models.User, len(users))
+			for _, user := range users {
+				byNickname[strings.ToLower(user.Nickname)] = user
+			}
+			result := make(map[string]models.User, len(nicknames))
+			for _, nickname := range nicknames {
+				if user, ok := byNickname[strings.ToLower(nickname)]; ok {
+					result[nickname] = user
+				}
+			}
+			return result, nil
+		}),
+		forums: newLoader(ctx, func(ctx context.Context, slugs []string) (map[string]models.Forum, error) {
+			forums, err := h.uc.GetForumsBySlugs(ctx, slugs)
+			if err != nil {
+				return nil, err
+			}
+			bySlug := make(map[string]models.Forum, len(forums))
+			for _, f := range forums {
+				bySlug[strings.ToLower(f.Slug)] = f
+			}
+			result := make(map[string]models.Forum, len(slugs))
+			for _, slug := range slugs {
+				if f, ok := bySlug[strings.ToLower(slug)]; ok {
+					result[slug] = f
+				}
+			}
+			return result, nil
+		}),
+		threads: newLoader(ctx, func(ctx context.Context, ids []int) (map[int]models.Thread, error) {
+			threads, err := h.uc.GetThreadsByIds(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int]models.Thread, len(threads))
+			for _, thread := range threads {
+				result[thread.ID] = thread
+			}
+			return result, nil
+		}),
+	}
+}
@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	// batchWait is how long a loader keeps collecting keys after the first
+	// one. graphql-go resolves the elements of a list concurrently, so the
+	// lookups of one list level arrive within this window.
+	batchWait    = 2 * time.Millisecond
+	maxBatchSize = 500
+)
+
+// loader coalesces the lookups issued while a query resolves into batched
+// fetches and remembers the results for the rest of the request. It lives
+// for one request only, so it never serves stale rows to another one.
+type loader[K comparable, V any] struct {
+	ctx   context.Context
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	results map[K]*loadResult[V]
+	pending *loadBatch[K, V]
+}
+
+type loadResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loadBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loadResult[V]
+	once    sync.Once
+}
+
+// newLoader builds a loader whose fetch returns the values found for keys;
+// keys missing from the map resolve to models.ErrorNotFound.
+func newLoader[K comparable, V any](ctx context.Context, fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		ctx:     ctx,
+		fetch:   fetch,
+		results: make(map[K]*loadResult[V]),
+	}
+}
+
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	result, ok := l.results[key]
+	if !ok {
+		result = &loadResult[V]{done: make(chan struct{})}
+		l.results[key] = result
+		l.enqueue(key, result)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds key to the open batch, starting one if needed. It must be
+// called with l.mu held.
+func (l *loader[K, V]) enqueue(key K, result *loadResult[V]) {
+	batch := l.pending
+	if batch == nil {
+		batch = &loadBatch[K, V]{}
+		l.pending = batch
+		time.AfterFunc(batchWait, func() { l.dispatch(batch) })
+	}
+	batch.keys = append(batch.keys, key)
+	batch.results = append(batch.results, result)
+	if len(batch.keys) >= maxBatchSize {
+		l.pending = nil
+		go l.dispatch(batch)
+	}
+}
+
+func (l *loader[K, V]) dispatch(batch *loadBatch[K, V]) {
+	batch.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == batch {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		values, err := l.safeFetch(batch.keys)
+		for i, key := range batch.keys {
+			result := batch.results[i]
+			if err != nil {
+				result.err = err
+			} else if value, ok := values[key]; ok {
+				result.value = value
+			} else {
+				result.err = models.ErrorNotFound
+			}
+			close(result.done)
+		}
+	})
+}
+
+// safeFetch runs fetch outside of any resolver, on a timer goroutine, where
+// graphql-go cannot recover a panic; it is turned into the batch error
+// instead of taking the process down.
+func (l *loader[K, V]) safeFetch(keys []K) (values map[K]V, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("panic in GraphQL batch load: %v\n%s", recovered, debug.Stack())
+			values, err = nil, models.ErrorInternal
+		}
+	}()
+	return l.fetch(l.ctx, keys)
+}
+
+// loaders holds the per-request loaders of the entities that other objects
+// refer to.
+type loaders struct {
+	users   *loader[string, models.User]
+	forums  *loader[string, models.Forum]
+	threads *loader[int, models.Thread]
+}
@@ -0,0 +1,640 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type resolver struct {
+	uc  forum.ForumUsecase
+	cfg *config.Config
+}
+
+type loadersKey struct{}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ Nickname string }) (*userResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	user, err := r.uc.GetUser(ctx, args.Nickname)
+	if errors.Is(err, models.ErrorNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourceUser, args.Nickname)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *resolver) Forum(ctx context.Context, args struct{ Slug string }) (*forumResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	result, err := r.uc.GetForum(ctx, args.Slug)
+	if errors.Is(err, models.ErrorNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourceForum, args.Slug)
+	}
+	return &forumResolver{r: r, forum: result}, nil
+}
+
+func (r *resolver) Thread(ctx context.Context, args struct{ SlugOrId string }) (*threadResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	thread, err := r.uc.CheckThreadByIdOrSlug(ctx, args.SlugOrId)
+	if errors.Is(err, models.ErrorNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, args.SlugOrId)
+	}
+	return &threadResolver{r: r, thread: thread}, nil
+}
+
+func (r *resolver) Post(ctx context.Context, args struct{ ID int32 }) (*postResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(int(args.ID))
+	result, err := r.uc.GetPost(ctx, id, nil)
+	if errors.Is(err, models.ErrorNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourcePost, id)
+	}
+	return &postResolver{r: r, post: result.Post}, nil
+}
+
+func (r *resolver) Status() *statusResolver {
+	return &statusResolver{status: r.uc.GetStatus()}
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct {
+	Nickname string
+	Input    struct {
+		Fullname string
+		About    *string
+		Email    string
+		Password *string
+	}
+}) (*userResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	user := models.User{
+		Nickname: args.Nickname,
+		Fullname: args.Input.Fullname,
+		About:    value(args.Input.About),
+		Email:    args.Input.Email,
+		Password: value(args.Input.Password),
+	}
+	result, err := r.uc.CreateUser(ctx, user)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceUser, args.Nickname)
+	}
+	return &userResolver{user: result[0]}, nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	Nickname string
+	Input    struct {
+		Fullname *string
+		About    *string
+		Email    *string
+	}
+	Version *int32
+}) (*userResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	user := models.User{
+		Nickname: args.Nickname,
+		Fullname: value(args.Input.Fullname),
+		About:    value(args.Input.About),
+		Email:    value(args.Input.Email),
+		Version:  int(value(args.Version)),
+	}
+	result, err := r.uc.UpdateUser(ctx, user)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceUser, args.Nickname)
+	}
+	return &userResolver{user: result}, nil
+}
+
+func (r *resolver) CreateForum(ctx context.Context, args struct {
+	Input struct {
+		Slug  string
+		Title string
+		User  string
+	}
+}) (*forumResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	forumInfo := models.Forum{Slug: args.Input.Slug, Title: args.Input.Title, User: args.Input.User}
+	result, err := r.uc.CreateForum(ctx, forumInfo)
+	if errors.Is(err, models.ErrorConflict) {
+		return nil, resolverError(err, utils.ResourceForum, forumInfo.Slug)
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourceUser, forumInfo.User)
+	}
+	return &forumResolver{r: r, forum: result}, nil
+}
+
+func (r *resolver) CreateThread(ctx context.Context, args struct {
+	Forum string
+	Input struct {
+		Title   string
+		Author  string
+		Message string
+		Slug    *string
+		Created *graphqlgo.Time
+	}
+}) (*threadResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	thread := models.Thread{
+		Forum:   args.Forum,
+		Title:   args.Input.Title,
+		Author:  args.Input.Author,
+		Message: args.Input.Message,
+		Slug:    value(args.Input.Slug),
+	}
+	if args.Input.Created != nil {
+		thread.Created = args.Input.Created.Time
+	}
+	result, err := r.uc.CreateThread(ctx, thread)
+	if errors.Is(err, models.ErrorNotFound) {
+		// The repository does not tell a missing author from a missing forum.
+		gqlErr := resolverError(err, utils.ResourceForum, args.Forum)
+		gqlErr.body.Message = fmt.Sprintf("Can't find forum %q or user %q", args.Forum, thread.Author)
+		return nil, gqlErr
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, thread.Slug)
+	}
+	return &threadResolver{r: r, thread: result}, nil
+}
+
+func (r *resolver) UpdateThread(ctx context.Context, args struct {
+	SlugOrId string
+	Input    struct {
+		Title   *string
+		Message *string
+	}
+	Version *int32
+}) (*threadResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	thread := models.Thread{
+		Title:   value(args.Input.Title),
+		Message: value(args.Input.Message),
+		Version: int(value(args.Version)),
+	}
+	if id, err := strconv.Atoi(args.SlugOrId); err != nil {
+		thread.Slug = args.SlugOrId
+	} else {
+		thread.ID = id
+	}
+	result, err := r.uc.UpdateThread(ctx, thread)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, args.SlugOrId)
+	}
+	return &threadResolver{r: r, thread: result}, nil
+}
+
+func (r *resolver) CreatePosts(ctx context.Context, args struct {
+	Thread string
+	Posts  []struct {
+		Author  string
+		Message string
+		Parent  *int32
+	}
+}) ([]*postResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, len(args.Posts)); err != nil {
+		return nil, err
+	}
+	thread, err := r.uc.CheckThreadByIdOrSlug(ctx, args.Thread)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, args.Thread)
+	}
+	if len(args.Posts) == 0 {
+		return []*postResolver{}, nil
+	}
+
+	posts := make(models.PostsList, 0, len(args.Posts))
+	for _, post := range args.Posts {
+		posts = append(posts, models.Post{Author: post.Author, Message: post.Message, Parent: int(value(post.Parent))})
+	}
+	created, err := r.uc.CreatePosts(ctx, posts, thread)
+	if errors.Is(err, models.ErrorNotFound) {
+		gqlErr := resolverError(err, utils.ResourceUser, "")
+		gqlErr.body.Message = "Can't find post author"
+		return nil, gqlErr
+	} else if errors.Is(err, models.ErrorConflict) {
+		gqlErr := resolverError(err, utils.ResourcePost, "")
+		gqlErr.body.Message = "Parent post was created in another thread"
+		return nil, gqlErr
+	} else if err != nil {
+		return nil, resolverError(err, utils.ResourcePost, "")
+	}
+	return r.posts(created), nil
+}
+
+func (r *resolver) UpdatePost(ctx context.Context, args struct {
+	ID      int32
+	Message string
+	Version *int32
+}) (*postResolver, error) {
+	if err := requireScope(ctx, auth.ScopePost); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	update := models.PostUpdate{ID: int(args.ID), Message: args.Message, Version: int(value(args.Version))}
+	result, err := r.uc.UpdatePost(ctx, update)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourcePost, strconv.Itoa(update.ID))
+	}
+	return &postResolver{r: r, post: result}, nil
+}
+
+func (r *resolver) Vote(ctx context.Context, args struct {
+	Thread   string
+	Nickname string
+	Voice    int32
+}) (*threadResolver, error) {
+	if err := requireScope(ctx, auth.ScopeVote); err != nil {
+		return nil, err
+	}
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	thread, err := r.uc.CheckThreadByIdOrSlug(ctx, args.Thread)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, args.Thread)
+	}
+	vote := models.Vote{Nickname: args.Nickname, Voice: int(args.Voice), Thread: thread.ID}
+	if err = r.uc.Vote(ctx, vote); err != nil {
+		return nil, resolverError(err, utils.ResourceUser, vote.Nickname)
+	}
+	thread, err = r.uc.CheckThreadByIdOrSlug(ctx, args.Thread)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, args.Thread)
+	}
+	return &threadResolver{r: r, thread: thread}, nil
+}
+
+func (r *resolver) Clear(ctx context.Context) (bool, error) {
+	if err := requireScope(ctx, auth.ScopeAdmin); err != nil {
+		return false, err
+	}
+	if !r.cfg.Features.AllowClear {
+		return false, &fieldError{
+			status: http.StatusForbidden,
+			body:   models.Error{Code: models.CodeForbidden, Message: "Clear is disabled"},
+		}
+	}
+	if err := r.uc.Clear(ctx); err != nil {
+		return false, resolverError(err, "", "")
+	}
+	return true, nil
+}
+
+// requireScope does for a mutation what the RequireScope middleware does
+// for a route, since all of them share the one GraphQL route.
+func requireScope(ctx context.Context, scope auth.Scope) error {
+	if principal, ok := auth.FromContext(ctx); ok && !principal.Can(scope) {
+		return resolverError(fmt.Errorf("%w: API key lacks the %s scope", models.ErrorForbidden, scope), "", "")
+	}
+	return nil
+}
+
+func (r *resolver) posts(posts []models.Post) []*postResolver {
+	result := make([]*postResolver, 0, len(posts))
+	for _, post := range posts {
+		result = append(result, &postResolver{r: r, post: post})
+	}
+	return result
+}
+
+// listLimit is the limit a list field passes on and charges to the budget;
+// lists without one get the default page size.
+func (r *resolver) listLimit(ctx context.Context, limit *int32) (string, error) {
+	n := r.cfg.Pagination.DefaultLimit
+	if limit != nil {
+		n = int(*limit)
+	}
+	if n > 0 {
+		if err := charge(ctx, n); err != nil {
+			return "", err
+		}
+	}
+	return strconv.Itoa(n), nil
+}
+
+type userResolver struct {
+	user models.User
+}
+
+func (u *userResolver) Nickname() string {
+	return u.user.Nickname
+}
+
+func (u *userResolver) Fullname() string {
+	return u.user.Fullname
+}
+
+func (u *userResolver) About() string {
+	return u.user.About
+}
+
+func (u *userResolver) Email() string {
+	return u.user.Email
+}
+
+func (u *userResolver) Version() *int32 {
+	return version(u.user.Version)
+}
+
+type forumResolver struct {
+	r     *resolver
+	forum models.Forum
+}
+
+func (f *forumResolver) Slug() string {
+	return f.forum.Slug
+}
+
+func (f *forumResolver) Title() string {
+	return f.forum.Title
+}
+
+func (f *forumResolver) User(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, f.forum.User)
+}
+
+func (f *forumResolver) PostCount() int32 {
+	return int32(f.forum.Posts)
+}
+
+func (f *forumResolver) ThreadCount() int32 {
+	return int32(f.forum.Threads)
+}
+
+func (f *forumResolver) Threads(ctx context.Context, args struct {
+	Limit *int32
+	Since *graphqlgo.Time
+	Desc  *bool
+}) ([]*threadResolver, error) {
+	limit, err := f.r.listLimit(ctx, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	since := ""
+	if args.Since != nil {
+		since = args.Since.Time.Format(time.RFC3339Nano)
+	}
+	threads, err := f.r.uc.GetThreads(ctx, f.forum.Slug, limit, since, boolArg(args.Desc))
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceForum, f.forum.Slug)
+	}
+	result := make([]*threadResolver, 0, len(threads))
+	for _, thread := range threads {
+		result = append(result, &threadResolver{r: f.r, thread: thread})
+	}
+	return result, nil
+}
+
+func (f *forumResolver) Users(ctx context.Context, args struct {
+	Limit *int32
+	Since *string
+	Desc  *bool
+}) ([]*userResolver, error) {
+	limit, err := f.r.listLimit(ctx, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	users, err := f.r.uc.GetUsers(ctx, f.forum.Slug, limit, value(args.Since), boolArg(args.Desc))
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceForum, f.forum.Slug)
+	}
+	result := make([]*userResolver, 0, len(users))
+	for _, user := range users {
+		result = append(result, &userResolver{user: user})
+	}
+	return result, nil
+}
+
+type threadResolver struct {
+	r      *resolver
+	thread models.Thread
+}
+
+func (t *threadResolver) ID() int32 {
+	return int32(t.thread.ID)
+}
+
+func (t *threadResolver) Slug() *string {
+	if t.thread.Slug == "" {
+		return nil
+	}
+	return &t.thread.Slug
+}
+
+func (t *threadResolver) Title() string {
+	return t.thread.Title
+}
+
+func (t *threadResolver) Message() string {
+	return t.thread.Message
+}
+
+func (t *threadResolver) Votes() int32 {
+	return int32(t.thread.Votes)
+}
+
+func (t *threadResolver) Created() graphqlgo.Time {
+	return graphqlgo.Time{Time: t.thread.Created}
+}
+
+func (t *threadResolver) Version() *int32 {
+	return version(t.thread.Version)
+}
+
+func (t *threadResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, t.thread.Author)
+}
+
+func (t *threadResolver) Forum(ctx context.Context) (*forumResolver, error) {
+	return loadForum(ctx, t.r, t.thread.Forum)
+}
+
+func (t *threadResolver) Posts(ctx context.Context, args struct {
+	Limit *int32
+	Since *int32
+	Sort  *string
+	Desc  *bool
+}) ([]*postResolver, error) {
+	limit, err := t.r.listLimit(ctx, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	since := ""
+	if args.Since != nil {
+		since = strconv.Itoa(int(*args.Since))
+	}
+	posts, err := t.r.uc.GetThreadPosts(ctx, limit, since, boolArg(args.Desc), value(args.Sort), t.thread.ID)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, strconv.Itoa(t.thread.ID))
+	}
+	return t.r.posts(posts), nil
+}
+
+type postResolver struct {
+	r    *resolver
+	post models.Post
+}
+
+func (p *postResolver) ID() int32 {
+	return int32(p.post.ID)
+}
+
+func (p *postResolver) Parent() int32 {
+	return int32(p.post.Parent)
+}
+
+func (p *postResolver) Message() string {
+	return p.post.Message
+}
+
+func (p *postResolver) IsEdited() bool {
+	return p.post.IsEdited
+}
+
+func (p *postResolver) Created() graphqlgo.Time {
+	return graphqlgo.Time{Time: p.post.Created}
+}
+
+func (p *postResolver) Version() *int32 {
+	return version(p.post.Version)
+}
+
+func (p *postResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, p.post.Author)
+}
+
+func (p *postResolver) Forum(ctx context.Context) (*forumResolver, error) {
+	return loadForum(ctx, p.r, p.post.Forum)
+}
+
+func (p *postResolver) Thread(ctx context.Context) (*threadResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	thread, err := loadersFrom(ctx).threads.Load(ctx, p.post.Thread)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceThread, strconv.Itoa(p.post.Thread))
+	}
+	return &threadResolver{r: p.r, thread: thread}, nil
+}
+
+type statusResolver struct {
+	status models.Status
+}
+
+func (s *statusResolver) User() int32 {
+	return int32(s.status.UsersCount)
+}
+
+func (s *statusResolver) Forum() int32 {
+	return int32(s.status.ForumsCount)
+}
+
+func (s *statusResolver) Thread() int32 {
+	return int32(s.status.ThreadsCount)
+}
+
+func (s *statusResolver) Post() int32 {
+	return int32(s.status.PostsCount)
+}
+
+func loadUser(ctx context.Context, nickname string) (*userResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	user, err := loadersFrom(ctx).users.Load(ctx, nickname)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceUser, nickname)
+	}
+	return &userResolver{user: user}, nil
+}
+
+func loadForum(ctx context.Context, r *resolver, slug string) (*forumResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	result, err := loadersFrom(ctx).forums.Load(ctx, slug)
+	if err != nil {
+		return nil, resolverError(err, utils.ResourceForum, slug)
+	}
+	return &forumResolver{r: r, forum: result}, nil
+}
+
+func value[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func boolArg(p *bool) string {
+	if p == nil {
+		return ""
+	}
+	return strconv.FormatBool(*p)
+}
+
+// version maps the zero version of rows loaded without one to null.
+func version(v int) *int32 {
+	if v <= 0 {
+		return nil
+	}
+	n := int32(v)
+	return &n
+}
@@ -2,7 +2,10 @@ package forum
 
 import (
 	"context"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/events"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"time"
 )
 
 type ForumUsecase interface {
@@ -11,6 +14,11 @@ type ForumUsecase interface {
 	UpdateUser(ctx context.Context, user models.User) (models.User, error)
 	GetUsers(ctx context.Context, slug, limit, since, desc string) ([]models.User, error)
 
+	Login(ctx context.Context, credentials models.Credentials) (models.Session, error)
+	Refresh(ctx context.Context, request models.RefreshRequest) (models.Session, error)
+	Logout(ctx context.Context) error
+	Authenticate(ctx context.Context, token string) (auth.Principal, error)
+
 	CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
 	GetForum(ctx context.Context, slug string) (models.Forum, error)
 
@@ -25,10 +33,38 @@ type ForumUsecase interface {
 
 	GetPost(ctx context.Context, id string, related []string) (models.PostFull, error)
 	GetThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int) ([]models.Post, error)
+	StreamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error
 	UpdatePost(ctx context.Context, post models.PostUpdate) (models.Post, error)
 
+	SubscribeThread(threadId int, lastEventID string) (*events.Subscription, error)
+
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, slug, id string) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, update models.WebhookUpdate) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, slug, id string) error
+	GetWebhookDeliveries(ctx context.Context, slug, id, limit, since, status, desc string) ([]models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, slug, id, deliveryId string) (models.WebhookDelivery, error)
+
+	GetModerators(ctx context.Context, slug string) ([]models.User, error)
+	AddModerator(ctx context.Context, slug, nickname string) error
+	RemoveModerator(ctx context.Context, slug, nickname string) error
+	SetAdmin(ctx context.Context, nickname string, admin bool) error
+
+	CreateAPIKey(ctx context.Context, nickname string, key models.APIKey) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context, nickname string) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, nickname, id string) error
+
+	GetThreadsPage(ctx context.Context, slug, limit, cursor, desc string) (models.ThreadsPage, error)
+	GetThreadPostsPage(ctx context.Context, limit, cursor, desc, sort string, threadId int) (models.PostsPage, error)
+	GetUsersPage(ctx context.Context, slug, limit, cursor, desc string) (models.UsersPage, error)
+
+	GetUsersByNicknames(ctx context.Context, nicknames []string) ([]models.User, error)
+	GetForumsBySlugs(ctx context.Context, slugs []string) ([]models.Forum, error)
+	GetThreadsByIds(ctx context.Context, ids []int) ([]models.Thread, error)
+
 	GetStatus() models.Status
-	Clear()
+	Clear(ctx context.Context) error
 }
 
 type ForumRepository interface {
@@ -36,6 +72,23 @@ type ForumRepository interface {
 	CreateUser(ctx context.Context, user models.User) ([]models.User, error)
 	UpdateUser(ctx context.Context, user models.User) (models.User, error)
 
+	GetUserCredentials(ctx context.Context, nickname string) (string, string, error)
+	CreateSession(ctx context.Context, id, nickname string, refreshHash []byte, expires time.Time) error
+	RotateSession(ctx context.Context, oldHash, newHash []byte, expires time.Time) (string, string, error)
+	DeleteSession(ctx context.Context, id string) error
+
+	GetRoles(ctx context.Context, nickname, forum string) (auth.Role, error)
+	SetAdmin(ctx context.Context, nickname string, admin bool) error
+	GetModerators(ctx context.Context, forum string) ([]models.User, error)
+	AddModerator(ctx context.Context, forum, nickname string) error
+	RemoveModerator(ctx context.Context, forum, nickname string) error
+
+	CreateAPIKey(ctx context.Context, key models.APIKey, keyHash []byte) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context, nickname string) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash []byte) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id string) error
+	DeleteAPIKey(ctx context.Context, nickname, id string) error
+
 	CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
 	GetForum(ctx context.Context, slug string) (models.Forum, error)
 	GetUsers(ctx context.Context, slug, limit, since, desc string) ([]models.User, error)
@@ -52,8 +105,26 @@ type ForumRepository interface {
 
 	GetPost(ctx context.Context, id int, related []string) (models.PostFull, error)
 	GetThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int) ([]models.Post, error)
+	StreamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error
 	UpdatePost(ctx context.Context, post models.PostUpdate) (models.Post, error)
 
+	GetThreadsPage(ctx context.Context, slug string, after *models.PageKey, desc bool, limit int) ([]models.Thread, []models.PageKey, error)
+	GetThreadPostsPage(ctx context.Context, threadId int, sort string, after *models.PageKey, desc bool, limit int) ([]models.Post, []models.PageKey, error)
+	GetUsersPage(ctx context.Context, slug string, after *models.PageKey, desc bool, limit int) ([]models.User, []models.PageKey, error)
+
+	GetUsersByNicknames(ctx context.Context, nicknames []string) ([]models.User, error)
+	GetForumsBySlugs(ctx context.Context, slugs []string) ([]models.Forum, error)
+	GetThreadsByIds(ctx context.Context, ids []int) ([]models.Thread, error)
+
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, forum string) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, forum string, id int) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, update models.WebhookUpdate) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, forum string, id int) error
+	EnqueueWebhookDeliveries(ctx context.Context, forum, event string, payload []byte) error
+	GetWebhookDeliveries(ctx context.Context, webhook int, status string, since int64, desc bool, limit int) ([]models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, webhook int, id int64) (models.WebhookDelivery, error)
+
 	GetStatus() models.Status
 	Clear()
 }
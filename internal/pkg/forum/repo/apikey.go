@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+)
+
+const (
+	apiKeyColumns = `id, name, nickname, scopes, created, lastused`
+	// apiKeyOfUser leaves out the keys of users gone in a crash, which the
+	// logged api_key table outlives.
+	apiKeyOfUser = `EXISTS (SELECT 1 FROM "user" u WHERE u.nickname = api_key.nickname)`
+
+	CreateAPIKey    = `INSERT INTO api_key (id, name, nickname, scopes, keyhash) SELECT $1, $2, nickname, $4, $5 FROM "user" WHERE nickname = $3 RETURNING ` + apiKeyColumns
+	GetAPIKeys      = `SELECT ` + apiKeyColumns + ` FROM api_key WHERE nickname = $1 AND ` + apiKeyOfUser + ` ORDER BY created, id`
+	GetAPIKeyByHash = `SELECT ` + apiKeyColumns + ` FROM api_key WHERE keyhash = $1 AND ` + apiKeyOfUser
+	TouchAPIKey     = `UPDATE api_key SET lastused = now() WHERE id = $1`
+	DeleteAPIKey    = `DELETE FROM api_key WHERE nickname = $1 AND id = $2`
+)
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Nickname, &key.Scopes, &key.Created, &key.LastUsed)
+	return key, err
+}
+
+// CreateAPIKey returns models.ErrorNotFound when the user does not exist.
+func (r *ForumRepository) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash []byte) (models.APIKey, error) {
+	defer metrics.ObserveQuery("CreateAPIKey")()
+	result, err := scanAPIKey(r.conn.QueryRow(ctx, CreateAPIKey, key.ID, key.Name, key.Nickname, key.Scopes, keyHash))
+	return result, notFound(err)
+}
+
+func (r *ForumRepository) GetAPIKeys(ctx context.Context, nickname string) ([]models.APIKey, error) {
+	defer metrics.ObserveQuery("GetAPIKeys")()
+	rows, err := r.conn.Query(ctx, GetAPIKeys, nickname)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *ForumRepository) GetAPIKeyByHash(ctx context.Context, keyHash []byte) (models.APIKey, error) {
+	defer metrics.ObserveQuery("GetAPIKeyByHash")()
+	key, err := scanAPIKey(r.conn.QueryRow(ctx, GetAPIKeyByHash, keyHash))
+	return key, notFound(err)
+}
+
+func (r *ForumRepository) TouchAPIKey(ctx context.Context, id string) error {
+	defer metrics.ObserveQuery("TouchAPIKey")()
+	_, err := r.conn.Exec(ctx, TouchAPIKey, id)
+	return err
+}
+
+func (r *ForumRepository) DeleteAPIKey(ctx context.Context, nickname, id string) error {
+	defer metrics.ObserveQuery("DeleteAPIKey")()
+	tag, err := r.conn.Exec(ctx, DeleteAPIKey, nickname, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
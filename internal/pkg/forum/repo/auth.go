@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"time"
+)
+
+const (
+	GetUserCredentials = `SELECT nickname, coalesce(passwordhash, '') FROM "user" WHERE nickname = $1`
+	// CreateSession also drops the expired sessions of the user, so the
+	// sessions of users who stop logging in are the only ones left behind.
+	CreateSession = `WITH expired AS (DELETE FROM user_session WHERE nickname = $2 AND expires < now())
+		INSERT INTO user_session (id, nickname, refreshhash, expires) VALUES ($1, $2, $3, $4)`
+	// RotateSession swaps the refresh token of a live session, so each one
+	// is used once.
+	RotateSession = `UPDATE user_session SET refreshhash = $2, expires = $3 WHERE refreshhash = $1 AND expires > now() RETURNING id, nickname`
+	DeleteSession = `DELETE FROM user_session WHERE id = $1`
+)
+
+// GetUserCredentials returns the nickname as stored and the password hash,
+// empty when the user has no password.
+func (r *ForumRepository) GetUserCredentials(ctx context.Context, nickname string) (string, string, error) {
+	defer metrics.ObserveQuery("GetUserCredentials")()
+	var stored, hash string
+	err := r.conn.QueryRow(ctx, GetUserCredentials, nickname).Scan(&stored, &hash)
+	return stored, hash, notFound(err)
+}
+
+func (r *ForumRepository) CreateSession(ctx context.Context, id, nickname string, refreshHash []byte, expires time.Time) error {
+	defer metrics.ObserveQuery("CreateSession")()
+	_, err := r.conn.Exec(ctx, CreateSession, id, nickname, refreshHash, expires)
+	return err
+}
+
+// RotateSession replaces the refresh token hashed as oldHash and returns
+// the session id and nickname, or models.ErrorNotFound when the token is
+// unknown, used up or expired.
+func (r *ForumRepository) RotateSession(ctx context.Context, oldHash, newHash []byte, expires time.Time) (string, string, error) {
+	defer metrics.ObserveQuery("RotateSession")()
+	var id, nickname string
+	err := r.conn.QueryRow(ctx, RotateSession, oldHash, newHash, expires).Scan(&id, &nickname)
+	return id, nickname, notFound(err)
+}
+
+func (r *ForumRepository) DeleteSession(ctx context.Context, id string) error {
+	defer metrics.ObserveQuery("DeleteSession")()
+	_, err := r.conn.Exec(ctx, DeleteSession, id)
+	return err
+}
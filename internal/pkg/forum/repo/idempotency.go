@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/idempotency"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"time"
+)
+
+const (
+	// ReserveIdempotencyKey inserts the key, or takes over one whose response
+	// has expired or whose request has held it past the lease; either way the
+	// reservation gets a new Created.
+	ReserveIdempotencyKey = `INSERT INTO idempotency_key (scope, key, requesthash) VALUES ($1, $2, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET requesthash = excluded.requesthash, status = NULL, headers = NULL, body = NULL, created = clock_timestamp()
+		WHERE idempotency_key.created < now() - make_interval(secs => $4)
+			OR idempotency_key.status IS NULL AND idempotency_key.created < now() - make_interval(secs => $5)
+		RETURNING created`
+	GetIdempotencyKey      = `SELECT requesthash, coalesce(status, 0), headers, body, created FROM idempotency_key WHERE scope = $1 AND key = $2`
+	CompleteIdempotencyKey = `UPDATE idempotency_key SET status = $4, headers = $5, body = $6 WHERE scope = $1 AND key = $2 AND created = $3`
+	ReleaseIdempotencyKey  = `DELETE FROM idempotency_key WHERE scope = $1 AND key = $2 AND created = $3`
+	PurgeIdempotencyKeys   = `DELETE FROM idempotency_key WHERE created < $1`
+)
+
+func (r *ForumRepository) ReserveIdempotencyKey(ctx context.Context, scope, key string, hash []byte, ttl, lease time.Duration) (idempotency.Record, bool, error) {
+	defer metrics.ObserveQuery("ReserveIdempotencyKey")()
+	reservation := idempotency.Record{RequestHash: hash}
+	err := r.conn.QueryRow(ctx, ReserveIdempotencyKey, scope, key, hash, ttl.Seconds(), lease.Seconds()).Scan(&reservation.Created)
+	if err == nil {
+		return reservation, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return idempotency.Record{}, false, err
+	}
+
+	var record idempotency.Record
+	err = r.conn.QueryRow(ctx, GetIdempotencyKey, scope, key).Scan(&record.RequestHash, &record.Status, &record.Header, &record.Body, &record.Created)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Released between the two queries; the request holding it failed
+		// and the client may retry right away.
+		return idempotency.Record{}, false, models.ErrorConflict
+	}
+	return record, false, err
+}
+
+func (r *ForumRepository) CompleteIdempotencyKey(ctx context.Context, scope, key string, created time.Time, response idempotency.Record) error {
+	defer metrics.ObserveQuery("CompleteIdempotencyKey")()
+	_, err := r.conn.Exec(ctx, CompleteIdempotencyKey, scope, key, created, response.Status, response.Header, response.Body)
+	return err
+}
+
+func (r *ForumRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string, created time.Time) error {
+	defer metrics.ObserveQuery("ReleaseIdempotencyKey")()
+	_, err := r.conn.Exec(ctx, ReleaseIdempotencyKey, scope, key, created)
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the keys reserved before the given time.
+func (r *ForumRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("PurgeIdempotencyKeys")()
+	tag, err := r.conn.Exec(ctx, PurgeIdempotencyKeys, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -7,28 +7,34 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type ForumRepository struct {
 	conn   *pgxpool.Pool
+	cfg    *config.Config
 	Status models.Status
 }
 
-func NewForumRepo(connection *pgxpool.Pool) *ForumRepository {
+func NewForumRepo(connection *pgxpool.Pool, cfg *config.Config) *ForumRepository {
 	return &ForumRepository{
 		conn:   connection,
+		cfg:    cfg,
 		Status: models.Status{},
 	}
 }
 
 const (
-	GetUserByNickname                     = `SELECT email, fullname, nickname, about FROM "user" WHERE nickname=$1 LIMIT 1;`
+	GetUserByNickname                     = `SELECT email, fullname, nickname, about, version FROM "user" WHERE nickname=$1 LIMIT 1;`
 	GetUsersOnConflict                    = `SELECT email, fullname, nickname, about FROM "user" WHERE email = $1 or nickname = $2`
-	CreateUser                            = `INSERT INTO "user" (email, fullname, nickname, about) VALUES ($1, $2, $3, $4) RETURNING nickname;`
-	UpdateUser                            = `UPDATE "user" SET fullname=$1, email=$2, about=$3 WHERE nickname = $4 RETURNING nickname, fullname, about, email;`
+	CreateUser                            = `WITH stale_keys AS (DELETE FROM api_key WHERE nickname = $3) INSERT INTO "user" (email, fullname, nickname, about, passwordhash) VALUES ($1, $2, $3, $4, nullif($5, '')) RETURNING version;`
+	UpdateUser                            = `UPDATE "user" SET fullname=$1, email=$2, about=$3 WHERE nickname = $4 AND ($5 = 0 OR version = $5) RETURNING nickname, fullname, about, email, version;`
 	CheckIfUserExists                     = `SELECT nickname FROM "user" WHERE nickname =  $1`
 	CheckIfForumExists                    = `SELECT slug FROM "forum" WHERE slug = $1;`
 	CreateForum                           = `INSERT INTO "forum" (title, "user", slug) VALUES ($1, $2, $3) RETURNING slug;`
@@ -39,12 +45,12 @@ const (
 	GetThreadsWithSinceAsc                = `SELECT id, title, author, forum, message, votes, slug, created FROM "thread" WHERE forum=$1 AND created >= $2 ORDER BY created ASC LIMIT $3;`
 	GetThreadsDesc                        = `SELECT id, title, author, forum, message, votes, slug, created FROM "thread" WHERE forum=$1 ORDER BY created DESC LIMIT $2;`
 	GetThreadsAsc                         = `SELECT id, title, author, forum, message, votes, slug, created FROM "thread" WHERE forum=$1 ORDER BY created ASC LIMIT $2;`
-	SelectThreadById                      = `SELECT id, title, author, forum, message, votes, slug, created FROM "thread" WHERE id=$1 LIMIT 1;`
-	SelectThreadBySlug                    = `SELECT id, title, author, forum, message, votes, slug, created FROM "thread" WHERE slug=$1 LIMIT 1;`
+	SelectThreadById                      = `SELECT id, title, author, forum, message, votes, slug, created, coalesce(modified, created), version FROM "thread" WHERE id=$1 LIMIT 1;`
+	SelectThreadBySlug                    = `SELECT id, title, author, forum, message, votes, slug, created, coalesce(modified, created), version FROM "thread" WHERE slug=$1 LIMIT 1;`
 	InsertPostsStartQuery                 = `INSERT INTO "post"(author, created, forum, message, parent, thread) VALUES`
 	UpdateVote                            = `UPDATE "vote" SET voice=$1 WHERE author=$2 AND thread=$3;`
 	InsertVote                            = `INSERT INTO "vote"(author, voice, thread) VALUES ($1, $2, $3);`
-	SelectPostById                        = `SELECT author, message, created, forum, isedited, parent, thread FROM "post" WHERE id = $1 LIMIT 1;`
+	SelectPostById                        = `SELECT author, message, created, forum, isedited, parent, thread, coalesce(modified, created), version FROM "post" WHERE id = $1 LIMIT 1;`
 	GetPostsWithSinceDesc                 = `SELECT id, author, created, forum, isedited, message, parent, thread FROM "post" WHERE thread=$1 ORDER BY id DESC limit $2;`
 	GetPostsWithSinceAsc                  = `SELECT id, author, created, forum, isedited, message, parent, thread FROM "post" WHERE thread=$1 ORDER BY id ASC limit $2;`
 	GetPostsDesc                          = `SELECT id, author, created, forum, isedited, message, parent, thread FROM "post" WHERE thread=$1 AND id < $2 ORDER BY id DESC LIMIT $3;`
@@ -57,14 +63,32 @@ const (
 	GetPostsTreeWithLimitWithSinceAsc     = `SELECT "post".id, "post".author, "post".created, "post".forum, "post".isedited, "post".message, "post".parent, "post".thread FROM "post" JOIN "post" parent ON parent.id = $2 WHERE "post".path > parent.path AND "post".thread = $1 ORDER BY "post".path ASC, "post".id ASC LIMIT $3`
 	SelectTreeSinceNilDesc                = `SELECT "post".id, "post".author, "post".created, "post".forum, "post".isedited, "post".message, "post".parent, "post".thread FROM "post" JOIN "post" parent ON parent.id = $2 WHERE "post".path < parent.path AND "post".thread = $1 ORDER BY "post".path DESC, "post".id DESC`
 	SelectTreeSinceNilDescNil             = `SELECT "post".id, "post".author, "post".created, "post".forum, "post".isedited, "post".message, "post".parent, "post".thread FROM "post" JOIN "post" parent ON parent.id = $2 WHERE "post".path > parent.path AND "post".thread = $1 ORDER BY "post".path ASC, "post".id ASC`
-	UpdateThreadWithoutIdentifier         = "UPDATE thread SET title=coalesce(nullif($1, ''), title), message=coalesce(nullif($2, ''), message) WHERE %s RETURNING *"
+	UpdateThreadWithoutIdentifier         = "UPDATE thread SET title=coalesce(nullif($1, ''), title), message=coalesce(nullif($2, ''), message) WHERE %s AND ($4 = 0 OR version = $4) RETURNING id, title, author, forum, message, votes, slug, created, coalesce(modified, created), version"
 	GetUsersWithSinceDesc                 = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 AND nickname < $2 ORDER BY nickname DESC LIMIT $3;`
 	GetUsersWithSinceAsc                  = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 AND nickname > $2 ORDER BY nickname ASC LIMIT $3;`
 	GetUsersDesc                          = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 ORDER BY nickname DESC LIMIT $2;`
 	GetUsersAsc                           = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 ORDER BY nickname ASC LIMIT $2;`
-	UpdatePostMessage                     = `UPDATE "post" SET message=coalesce(nullif($1, ''), message), isedited = CASE WHEN $1 = '' OR message = $1 THEN isedited ELSE TRUE END WHERE id=$2 RETURNING *`
+	UpdatePostMessage                     = `UPDATE "post" SET message=coalesce(nullif($1, ''), message), isedited = CASE WHEN $1 = '' OR message = $1 THEN isedited ELSE TRUE END WHERE id=$2 AND ($3 = 0 OR version = $3) RETURNING id, author, created, forum, isedited, message, parent, thread, path, coalesce(modified, created), version`
 	GetThreadFromPost                     = `SELECT thread FROM "post" WHERE id = $1;`
-	DESTROY_DATABASE_DONT_TOCUH_DANGEROUS = `TRUNCATE TABLE "user", "forum", "thread", "post", "vote", "user_forum" CASCADE;`
+	PageThreadsAsc                        = `SELECT id, title, author, forum, message, votes, slug, created, version FROM "thread" WHERE forum=$1 ORDER BY created, id LIMIT $2;`
+	PageThreadsDesc                       = `SELECT id, title, author, forum, message, votes, slug, created, version FROM "thread" WHERE forum=$1 ORDER BY created DESC, id DESC LIMIT $2;`
+	PageThreadsAfterAsc                   = `SELECT id, title, author, forum, message, votes, slug, created, version FROM "thread" WHERE forum=$1 AND (created, id) > ($2, $3) ORDER BY created, id LIMIT $4;`
+	PageThreadsAfterDesc                  = `SELECT id, title, author, forum, message, votes, slug, created, version FROM "thread" WHERE forum=$1 AND (created, id) < ($2, $3) ORDER BY created DESC, id DESC LIMIT $4;`
+	PagePostsFlatAsc                      = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE thread=$1 AND id > $2 ORDER BY id LIMIT $3;`
+	PagePostsFlatDesc                     = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE thread=$1 AND id < $2 ORDER BY id DESC LIMIT $3;`
+	PagePostsTreeAsc                      = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE thread=$1 AND path > $2 ORDER BY path LIMIT $3;`
+	PagePostsTreeDesc                     = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE thread=$1 AND path < $2 ORDER BY path DESC LIMIT $3;`
+	PagePostsParentTreeAsc                = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE path[1] = ANY (SELECT id FROM "post" WHERE thread=$1 AND parent=0 AND id > $2 ORDER BY id LIMIT $3) ORDER BY path[1], path;`
+	PagePostsParentTreeDesc               = `SELECT id, author, created, forum, isedited, message, parent, thread, version, path FROM "post" WHERE path[1] = ANY (SELECT id FROM "post" WHERE thread=$1 AND parent=0 AND id < $2 ORDER BY id DESC LIMIT $3) ORDER BY path[1] DESC, path;`
+	PageUsersFirstAsc                     = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 ORDER BY nickname LIMIT $2;`
+	PageUsersFirstDesc                    = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 ORDER BY nickname DESC LIMIT $2;`
+	PageUsersAsc                          = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 AND nickname > $2 ORDER BY nickname LIMIT $3;`
+	PageUsersDesc                         = `SELECT nickname, fullname, about, email FROM "user_forum" WHERE slug=$1 AND nickname < $2 ORDER BY nickname DESC LIMIT $3;`
+	GetUsersByNicknames                   = `SELECT email, fullname, nickname, about, version FROM "user" WHERE nickname = ANY($1::citext[]);`
+	GetForumsBySlugs                      = `SELECT title, "user", slug, posts, threads FROM "forum" WHERE slug = ANY($1::citext[]);`
+	SelectThreadsByIds                    = `SELECT id, title, author, forum, message, votes, slug, created, coalesce(modified, created), version FROM "thread" WHERE id = ANY($1);`
+	CountRows                             = `SELECT (SELECT count(*) FROM "user"), (SELECT count(*) FROM "forum"), (SELECT count(*) FROM "thread"), (SELECT count(*) FROM "post");`
+	DESTROY_DATABASE_DONT_TOCUH_DANGEROUS = `TRUNCATE TABLE "user", "forum", "thread", "post", "vote", "user_forum", "webhook", "webhook_delivery", "idempotency_key", "user_session", "forum_moderator", "api_key" CASCADE;`
 )
 
 const (
@@ -73,11 +97,12 @@ const (
 )
 
 func (r *ForumRepository) GetUser(ctx context.Context, nickname string) (models.User, error) {
+	defer metrics.ObserveQuery("GetUser")()
 	var resultUser models.User
 
 	row := r.conn.QueryRow(ctx, GetUserByNickname, nickname)
 
-	err := row.Scan(&resultUser.Email, &resultUser.Fullname, &resultUser.Nickname, &resultUser.About)
+	err := row.Scan(&resultUser.Email, &resultUser.Fullname, &resultUser.Nickname, &resultUser.About, &resultUser.Version)
 	if err != nil {
 		return models.User{}, models.ErrorNotFound
 	}
@@ -85,10 +110,10 @@ func (r *ForumRepository) GetUser(ctx context.Context, nickname string) (models.
 	return resultUser, nil
 }
 
-func (r *ForumRepository) getUsersOnConflict(user models.User) []models.User {
+func (r *ForumRepository) getUsersOnConflict(ctx context.Context, user models.User) []models.User {
 	results := make([]models.User, 0)
 
-	rows, _ := r.conn.Query(context.Background(), GetUsersOnConflict, user.Email, user.Nickname)
+	rows, _ := r.conn.Query(ctx, GetUsersOnConflict, user.Email, user.Nickname)
 	defer rows.Close()
 
 	for rows.Next() {
@@ -99,13 +124,17 @@ func (r *ForumRepository) getUsersOnConflict(user models.User) []models.User {
 	return results
 }
 
+// CreateUser also deletes the API keys left under the nickname by a user
+// lost in a crash, so they do not pass to the new one.
 func (r *ForumRepository) CreateUser(ctx context.Context, user models.User) ([]models.User, error) {
-	_, err := r.conn.Exec(ctx, CreateUser, user.Email, user.Fullname, user.Nickname, user.About)
+	defer metrics.ObserveQuery("CreateUser")()
+	err := r.conn.QueryRow(ctx, CreateUser, user.Email, user.Fullname, user.Nickname, user.About, user.Password).Scan(&user.Version)
+	user.Password = ""
 	if err != nil {
 		if pqError, ok := err.(*pgconn.PgError); ok {
 			switch pqError.Code {
 			case DuplicatesKeyError:
-				us := r.getUsersOnConflict(user)
+				us := r.getUsersOnConflict(ctx, user)
 				return us, models.ErrorConflict
 			}
 		}
@@ -116,6 +145,7 @@ func (r *ForumRepository) CreateUser(ctx context.Context, user models.User) ([]m
 }
 
 func (r *ForumRepository) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	defer metrics.ObserveQuery("UpdateUser")()
 	updatedUser, err := r.GetUser(ctx, user.Nickname)
 	if err != nil {
 		return updatedUser, err
@@ -129,8 +159,12 @@ func (r *ForumRepository) UpdateUser(ctx context.Context, user models.User) (mod
 	if user.About != "" {
 		updatedUser.About = user.About
 	}
-	rows := r.conn.QueryRow(ctx, UpdateUser, updatedUser.Fullname, updatedUser.Email, updatedUser.About, updatedUser.Nickname)
-	err = rows.Scan(&updatedUser.Nickname, &updatedUser.Fullname, &updatedUser.About, &updatedUser.Email)
+	rows := r.conn.QueryRow(ctx, UpdateUser, updatedUser.Fullname, updatedUser.Email, updatedUser.About, updatedUser.Nickname, user.Version)
+	err = rows.Scan(&updatedUser.Nickname, &updatedUser.Fullname, &updatedUser.About, &updatedUser.Email, &updatedUser.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// The user was found above, so only the version can have excluded it.
+		return user, models.ErrorPreconditionFailed
+	}
 	if pqError, ok := err.(*pgconn.PgError); ok {
 		switch pqError.Code {
 		case DuplicatesKeyError:
@@ -155,6 +189,7 @@ func (r *ForumRepository) checkIfUserExists(ctx context.Context, nickname string
 }
 
 func (r *ForumRepository) GetForumBySlug(ctx context.Context, slug string) (models.Forum, error) {
+	defer metrics.ObserveQuery("GetForumBySlug")()
 	result := models.Forum{}
 
 	row := r.conn.QueryRow(ctx, GetForumBySlug, slug)
@@ -168,6 +203,7 @@ func (r *ForumRepository) GetForumBySlug(ctx context.Context, slug string) (mode
 }
 
 func (r *ForumRepository) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	defer metrics.ObserveQuery("CreateForum")()
 	user, err := r.checkIfUserExists(ctx, forum.User)
 	if err != nil {
 		return models.Forum{}, models.ErrorNotFound
@@ -191,6 +227,7 @@ func (r *ForumRepository) CreateForum(ctx context.Context, forum models.Forum) (
 }
 
 func (r *ForumRepository) GetForum(ctx context.Context, slug string) (models.Forum, error) {
+	defer metrics.ObserveQuery("GetForum")()
 	var resultForum models.Forum
 
 	row := r.conn.QueryRow(ctx, GetForumBySlug, slug)
@@ -216,6 +253,7 @@ func (r *ForumRepository) checkIfForumExists(ctx context.Context, slug string) (
 }
 
 func (r *ForumRepository) GetThread(ctx context.Context, slug string) (models.Thread, error) {
+	defer metrics.ObserveQuery("GetThread")()
 	var resultThread models.Thread
 
 	row := r.conn.QueryRow(ctx, GetThreadBySlug, slug)
@@ -229,6 +267,7 @@ func (r *ForumRepository) GetThread(ctx context.Context, slug string) (models.Th
 }
 
 func (r *ForumRepository) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	defer metrics.ObserveQuery("CreateThread")()
 	user, err := r.checkIfUserExists(ctx, thread.Author)
 	if err != nil {
 		return models.Thread{}, models.ErrorNotFound
@@ -272,6 +311,7 @@ func (r *ForumRepository) CreateThread(ctx context.Context, thread models.Thread
 }
 
 func (r *ForumRepository) GetThreads(ctx context.Context, slug, limit, since, desc string) ([]models.Thread, error) {
+	defer metrics.ObserveQuery("GetThreads")()
 	threads := make([]models.Thread, 0)
 	if since != "" {
 		if desc == "true" {
@@ -330,26 +370,89 @@ func (r *ForumRepository) GetThreads(ctx context.Context, slug, limit, since, de
 }
 
 func (r *ForumRepository) GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error) {
+	defer metrics.ObserveQuery("GetThreadBySlug")()
 	thread := models.Thread{}
 	row := r.conn.QueryRow(ctx, SelectThreadBySlug, slug)
 	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
-		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created)
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Modified, &thread.Version)
 	if err != nil {
 		return models.Thread{}, models.ErrorNotFound
 	}
-	return thread, models.ErrorConflict
+	return thread, nil
 }
 func (r *ForumRepository) GetThreadById(ctx context.Context, id int) (models.Thread, error) {
+	defer metrics.ObserveQuery("GetThreadById")()
 	thread := models.Thread{}
 	row := r.conn.QueryRow(ctx, SelectThreadById, id)
 	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
-		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created)
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Modified, &thread.Version)
 	if err != nil {
 		return models.Thread{}, models.ErrorNotFound
 	}
-	return thread, models.ErrorConflict
+	return thread, nil
+}
+
+// GetUsersByNicknames looks up several users in one query. Users that do
+// not exist are missing from the result, which is in no particular order.
+func (r *ForumRepository) GetUsersByNicknames(ctx context.Context, nicknames []string) ([]models.User, error) {
+	defer metrics.ObserveQuery("GetUsersByNicknames")()
+	rows, err := r.conn.Query(ctx, GetUsersByNicknames, nicknames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := make([]models.User, 0, len(nicknames))
+	for rows.Next() {
+		user := models.User{}
+		if err = rows.Scan(&user.Email, &user.Fullname, &user.Nickname, &user.About, &user.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// GetForumsBySlugs is GetUsersByNicknames for forums.
+func (r *ForumRepository) GetForumsBySlugs(ctx context.Context, slugs []string) ([]models.Forum, error) {
+	defer metrics.ObserveQuery("GetForumsBySlugs")()
+	rows, err := r.conn.Query(ctx, GetForumsBySlugs, slugs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	forums := make([]models.Forum, 0, len(slugs))
+	for rows.Next() {
+		forum := models.Forum{}
+		if err = rows.Scan(&forum.Title, &forum.User, &forum.Slug, &forum.Posts, &forum.Threads); err != nil {
+			return nil, err
+		}
+		forums = append(forums, forum)
+	}
+	return forums, rows.Err()
+}
+
+// GetThreadsByIds is GetUsersByNicknames for threads.
+func (r *ForumRepository) GetThreadsByIds(ctx context.Context, ids []int) ([]models.Thread, error) {
+	defer metrics.ObserveQuery("GetThreadsByIds")()
+	rows, err := r.conn.Query(ctx, SelectThreadsByIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	threads := make([]models.Thread, 0, len(ids))
+	for rows.Next() {
+		thread := models.Thread{}
+		err = rows.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
+			&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Modified, &thread.Version)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, thread)
+	}
+	return threads, rows.Err()
 }
 func (r *ForumRepository) CreatePosts(ctx context.Context, posts models.PostsList, thread models.Thread) (models.PostsList, error) {
+	defer metrics.ObserveQuery("CreatePosts")()
 	InsertPosts := InsertPostsStartQuery
 	var values []interface{}
 	created := time.Now()
@@ -375,7 +478,7 @@ func (r *ForumRepository) CreatePosts(ctx context.Context, posts models.PostsLis
 		}
 	}
 	InsertPosts = strings.TrimSuffix(InsertPosts, ",")
-	InsertPosts += ` RETURNING id, created, forum, isEdited, thread;`
+	InsertPosts += ` RETURNING id, created, forum, isEdited, thread, version;`
 
 	rows, err := r.conn.Query(ctx, InsertPosts, values...)
 	if err != nil {
@@ -385,7 +488,7 @@ func (r *ForumRepository) CreatePosts(ctx context.Context, posts models.PostsLis
 
 	for i := range posts {
 		if rows.Next() {
-			err := rows.Scan(&posts[i].ID, &posts[i].Created, &posts[i].Forum, &posts[i].IsEdited, &posts[i].Thread)
+			err := rows.Scan(&posts[i].ID, &posts[i].Created, &posts[i].Forum, &posts[i].IsEdited, &posts[i].Thread, &posts[i].Version)
 			if err != nil {
 				return nil, err
 			}
@@ -399,6 +502,7 @@ func (r *ForumRepository) CreatePosts(ctx context.Context, posts models.PostsLis
 }
 
 func (r *ForumRepository) Vote(ctx context.Context, vote models.Vote) error {
+	defer metrics.ObserveQuery("Vote")()
 	_, err := r.checkIfUserExists(ctx, vote.Nickname)
 	if err != nil {
 		return models.ErrorNotFound
@@ -414,13 +518,14 @@ func (r *ForumRepository) Vote(ctx context.Context, vote models.Vote) error {
 }
 
 func (r *ForumRepository) GetPost(ctx context.Context, id int, related []string) (models.PostFull, error) {
+	defer metrics.ObserveQuery("GetPost")()
 	postTmp := models.Post{}
 	postResult := models.PostFull{Author: nil, Forum: nil, Post: models.Post{}, Thread: nil}
 
 	postTmp.ID = id
 
 	row := r.conn.QueryRow(ctx, SelectPostById, id)
-	err := row.Scan(&postTmp.Author, &postTmp.Message, &postTmp.Created, &postTmp.Forum, &postTmp.IsEdited, &postTmp.Parent, &postTmp.Thread)
+	err := row.Scan(&postTmp.Author, &postTmp.Message, &postTmp.Created, &postTmp.Forum, &postTmp.IsEdited, &postTmp.Parent, &postTmp.Thread, &postTmp.Modified, &postTmp.Version)
 	if err != nil {
 		return postResult, models.ErrorNotFound
 	}
@@ -442,168 +547,168 @@ func (r *ForumRepository) GetPost(ctx context.Context, id int, related []string)
 	return postResult, nil
 }
 
-func (r *ForumRepository) getThreadPostsFlat(ctx context.Context, limit, since, desc string, id int) []models.Post {
-	result := make([]models.Post, 0)
-	if since == "" {
-		if desc == "true" {
-			rows, _ := r.conn.Query(ctx, GetPostsWithSinceDesc, id, limit)
-			defer rows.Close()
-			for rows.Next() {
-				post := models.Post{}
-				rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread)
-				result = append(result, post)
+// threadPostsQuery builds the query for one page of GetThreadPosts. Every
+// query ends up with a LIMIT no greater than pagination.max_page_size; the
+// tree and parent_tree queries without a page limit get that cap appended.
+func (r *ForumRepository) threadPostsQuery(limit, since, desc, sort string, id int) (string, []interface{}) {
+	maxRows := r.cfg.Pagination.MaxPageSize
+	capped := func(query string) string {
+		return fmt.Sprintf("%s LIMIT %d", strings.TrimSuffix(query, ";"), maxRows)
+	}
+
+	switch sort {
+	case "tree":
+		switch {
+		case limit == "" && since == "":
+			if desc == "true" {
+				return capped(GetPostsTreeDesc), []interface{}{id}
 			}
-		} else {
-			rows, _ := r.conn.Query(ctx, GetPostsWithSinceAsc, id, limit)
-			defer rows.Close()
-			for rows.Next() {
-				post := models.Post{}
-				rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread)
-				result = append(result, post)
+			return capped(GetPostsTreeAsc), []interface{}{id}
+		case limit != "" && since == "":
+			if desc == "true" {
+				return GetPostsTreeWithLimitDesc, []interface{}{id, limit}
 			}
-		}
-	} else {
-		if desc == "true" {
-			rows, _ := r.conn.Query(ctx, GetPostsDesc, id, since, limit)
-			defer rows.Close()
-			for rows.Next() {
-				post := models.Post{}
-				rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread)
-				result = append(result, post)
+			return GetPostsTreeWithLimitAsc, []interface{}{id, limit}
+		case limit == "":
+			if desc == "true" {
+				return capped(SelectTreeSinceNilDesc), []interface{}{id, since}
 			}
-		} else {
-			rows, _ := r.conn.Query(ctx, GetPostsAsc, id, since, limit)
-			defer rows.Close()
-			for rows.Next() {
-				post := models.Post{}
-				rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread)
-				result = append(result, post)
+			return capped(SelectTreeSinceNilDescNil), []interface{}{id, since}
+		default:
+			if desc == "true" {
+				return GetPostsTreeWithLimitWithSinceDesc, []interface{}{id, since, limit}
 			}
+			return GetPostsTreeWithLimitWithSinceAsc, []interface{}{id, since, limit}
 		}
-	}
-	return result
-}
-
-func (r *ForumRepository) getThreadPostsTree(ctx context.Context, limit, since, desc string, id int) []models.Post {
-	result := make([]models.Post, 0)
-	var (
-		rows       pgx.Rows
-		queryFinal string
-	)
-
-	if limit == "" && since == "" {
-		if desc == "true" {
-			queryFinal = GetPostsTreeDesc
-		} else {
-			queryFinal = GetPostsTreeAsc
+	case "parent_tree":
+		halfQuery := fmt.Sprintf(`SELECT id FROM "post" WHERE thread = %d AND parent = 0 `, id)
+		if since != "" {
+			if desc == "true" {
+				halfQuery += ` AND path[1] < ` + fmt.Sprintf(`(SELECT path[1] FROM "post" WHERE id = %s) `, since)
+			} else {
+				halfQuery += ` AND path[1] > ` + fmt.Sprintf(`(SELECT path[1] FROM "post" WHERE id = %s) `, since)
+			}
 		}
-		rows, _ = r.conn.Query(ctx, queryFinal, id)
-	} else if limit != "" && since == "" {
 		if desc == "true" {
-			queryFinal = GetPostsTreeWithLimitDesc
+			halfQuery += ` ORDER BY id DESC `
 		} else {
-			queryFinal = GetPostsTreeWithLimitAsc
+			halfQuery += ` ORDER BY id ASC `
 		}
-		rows, _ = r.conn.Query(ctx, queryFinal, id, limit)
-	} else if limit == "" && since != "" {
+		if limit != "" {
+			halfQuery += " LIMIT " + limit
+		}
+		fullQuery := fmt.Sprintf(`SELECT id, author, created, forum, isedited, message, parent, thread FROM "post" WHERE path[1] = ANY (%s) `, halfQuery)
 		if desc == "true" {
-			queryFinal = SelectTreeSinceNilDesc
+			fullQuery += ` ORDER BY path[1] DESC, path, id `
 		} else {
-			queryFinal = SelectTreeSinceNilDescNil
+			fullQuery += ` ORDER BY path[1] ASC, path, id `
+		}
+		return capped(fullQuery), nil
+	default:
+		if limit == "" {
+			limit = strconv.Itoa(r.cfg.Pagination.DefaultLimit)
+		}
+		if since == "" {
+			if desc == "true" {
+				return GetPostsWithSinceDesc, []interface{}{id, limit}
+			}
+			return GetPostsWithSinceAsc, []interface{}{id, limit}
 		}
-		rows, _ = r.conn.Query(ctx, queryFinal, id, since)
-	} else if limit != "" && since != "" {
 		if desc == "true" {
-			queryFinal = GetPostsTreeWithLimitWithSinceDesc
-		} else {
-			queryFinal = GetPostsTreeWithLimitWithSinceAsc
+			return GetPostsDesc, []interface{}{id, since, limit}
 		}
-		rows, _ = r.conn.Query(ctx, queryFinal, id, since, limit)
+		return GetPostsAsc, []interface{}{id, since, limit}
 	}
-
-	for rows.Next() {
-		post := models.Post{}
-		rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread)
-		result = append(result, post)
-	}
-	return result
 }
 
-func (r *ForumRepository) getThreadPostsParentTree(ctx context.Context, limit, since, desc string, id int) []models.Post {
-	result := make([]models.Post, 0)
-	var rows pgx.Rows
-	halfQuery := fmt.Sprintf(`SELECT id FROM "post" WHERE thread = %d AND parent = 0 `, id)
-	if since != "" {
-		if desc == "true" {
-			halfQuery += ` AND path[1] < ` + fmt.Sprintf(`(SELECT path[1] FROM "post" WHERE id = %s) `, since)
-		} else {
-			halfQuery += ` AND path[1] > ` + fmt.Sprintf(`(SELECT path[1] FROM "post" WHERE id = %s) `, since)
-		}
-	}
-	if desc == "true" {
-		halfQuery += ` ORDER BY id DESC `
-	} else {
-		halfQuery += ` ORDER BY id ASC `
-	}
-	if limit != "" {
-		halfQuery += " LIMIT " + limit
+// streamThreadPosts hands the posts to fn one row at a time, so a page is
+// never held in memory as a whole. An error from fn stops the scan and is
+// returned; closing the rows early releases the connection. With count set
+// every row also carries the size of the page, counted by the database in
+// the same snapshot, and count gets it before the first post.
+func (r *ForumRepository) streamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error {
+	query, args := r.threadPostsQuery(limit, since, desc, sort, threadId)
+	if count != nil {
+		// The window has no ORDER BY, so the rows keep the order of the page.
+		query = fmt.Sprintf(`SELECT *, count(*) OVER () FROM (%s) page`, strings.TrimSuffix(query, ";"))
+	}
+	rows, err := r.conn.Query(ctx, query, args...)
+	if err != nil {
+		return err
 	}
-	fullQuery := fmt.Sprintf(`SELECT id, author, created, forum, isedited, message, parent, thread FROM "post" WHERE path[1] = ANY (%s) `, halfQuery)
-	if desc == "true" {
-		fullQuery += ` ORDER BY path[1] DESC, path, id `
-	} else {
-		fullQuery += ` ORDER BY path[1] ASC, path, id `
+	defer rows.Close()
+	for first := true; rows.Next(); first = false {
+		post := models.Post{}
+		dest := []interface{}{&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message, &post.Parent, &post.Thread}
+		var total int
+		if count != nil {
+			dest = append(dest, &total)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+		if count != nil && first {
+			count(total)
+		}
+		if err = fn(post); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
 
-	rows, _ = r.conn.Query(ctx, fullQuery)
-	for rows.Next() {
-		var post models.Post
-		rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message,
-			&post.Parent, &post.Thread)
-		result = append(result, post)
-	}
-	return result
+func (r *ForumRepository) StreamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error {
+	defer metrics.ObserveQuery("StreamThreadPosts")()
+	return r.streamThreadPosts(ctx, limit, since, desc, sort, threadId, count, fn)
 }
+
 func (r *ForumRepository) GetThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int) ([]models.Post, error) {
+	defer metrics.ObserveQuery("GetThreadPosts")()
 	result := make([]models.Post, 0)
-	switch sort {
-	case "flat":
-		result = r.getThreadPostsFlat(ctx, limit, since, desc, threadId)
-	case "tree":
-		result = r.getThreadPostsTree(ctx, limit, since, desc, threadId)
-	case "parent_tree":
-		result = r.getThreadPostsParentTree(ctx, limit, since, desc, threadId)
-	default:
-		result = r.getThreadPostsFlat(ctx, limit, since, desc, threadId)
+	err := r.streamThreadPosts(ctx, limit, since, desc, sort, threadId, nil, func(post models.Post) error {
+		result = append(result, post)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return result, nil
 }
 func (r *ForumRepository) UpdateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	defer metrics.ObserveQuery("UpdateThread")()
 	result := models.Thread{}
+	var row pgx.Row
 	if thread.Slug == "" {
 		resultQuery := fmt.Sprintf(UpdateThreadWithoutIdentifier, `id=$3`)
-		row := r.conn.QueryRow(ctx, resultQuery, thread.Title, thread.Message, thread.ID)
-		err := row.Scan(&result.ID, &result.Title, &result.Author,
-			&result.Forum, &result.Message, &result.Votes, &result.Slug, &result.Created)
-		if err != nil {
-			return models.Thread{}, models.ErrorNotFound
-		}
+		row = r.conn.QueryRow(ctx, resultQuery, thread.Title, thread.Message, thread.ID, thread.Version)
 	} else {
 		resultQuery := fmt.Sprintf(UpdateThreadWithoutIdentifier, `slug=$3`)
-		row := r.conn.QueryRow(ctx, resultQuery, thread.Title, thread.Message, thread.Slug)
-		err := row.Scan(&result.ID, &result.Title, &result.Author,
-			&result.Forum, &result.Message, &result.Votes, &result.Slug, &result.Created)
+		row = r.conn.QueryRow(ctx, resultQuery, thread.Title, thread.Message, thread.Slug, thread.Version)
+	}
+	err := row.Scan(&result.ID, &result.Title, &result.Author,
+		&result.Forum, &result.Message, &result.Votes, &result.Slug, &result.Created, &result.Modified, &result.Version)
+	if err != nil {
+		if thread.Version == 0 {
+			return models.Thread{}, models.ErrorNotFound
+		}
+		// Tell a missing thread from one that moved past the expected version.
+		if thread.Slug == "" {
+			_, err = r.GetThreadById(ctx, thread.ID)
+		} else {
+			_, err = r.GetThreadBySlug(ctx, thread.Slug)
+		}
 		if err != nil {
 			return models.Thread{}, models.ErrorNotFound
 		}
+		return models.Thread{}, models.ErrorPreconditionFailed
 	}
 	return result, nil
 }
 
 func (r *ForumRepository) GetUsers(ctx context.Context, slug, limit, since, desc string) ([]models.User, error) {
+	defer metrics.ObserveQuery("GetUsers")()
 	if limit == "" {
-		limit = "100"
+		limit = strconv.Itoa(r.cfg.Pagination.DefaultLimit)
 	}
 	users := make([]models.User, 0)
 	if since != "" {
@@ -658,12 +763,20 @@ func (r *ForumRepository) GetUsers(ctx context.Context, slug, limit, since, desc
 	return users, nil
 }
 func (r *ForumRepository) UpdatePost(ctx context.Context, post models.PostUpdate) (models.Post, error) {
-	row := r.conn.QueryRow(ctx, UpdatePostMessage, post.Message, post.ID)
+	defer metrics.ObserveQuery("UpdatePost")()
+	row := r.conn.QueryRow(ctx, UpdatePostMessage, post.Message, post.ID, post.Version)
 	result := models.Post{}
 	err := row.Scan(&result.ID, &result.Author, &result.Created, &result.Forum,
-		&result.IsEdited, &result.Message, &result.Parent, &result.Thread, &result.Path)
+		&result.IsEdited, &result.Message, &result.Parent, &result.Thread, &result.Path, &result.Modified, &result.Version)
 	if err != nil {
-		return result, models.ErrorNotFound
+		if post.Version == 0 {
+			return models.Post{}, models.ErrorNotFound
+		}
+		var threadID int
+		if err = r.conn.QueryRow(ctx, GetThreadFromPost, post.ID).Scan(&threadID); err != nil {
+			return models.Post{}, models.ErrorNotFound
+		}
+		return models.Post{}, models.ErrorPreconditionFailed
 	}
 	return result, nil
 }
@@ -677,7 +790,171 @@ func (r *ForumRepository) GetStatus() models.Status {
 	}
 }
 
+// LoadStatus seeds the in-memory counters from the database so a restarted
+// process reports the same status as before.
+func (r *ForumRepository) LoadStatus(ctx context.Context) error {
+	defer metrics.ObserveQuery("LoadStatus")()
+	status := models.Status{}
+	err := r.conn.QueryRow(ctx, CountRows).Scan(&status.UsersCount, &status.ForumsCount, &status.ThreadsCount, &status.PostsCount)
+	if err != nil {
+		return err
+	}
+	r.Status = status
+	return nil
+}
+
 func (r *ForumRepository) Clear() {
+	defer metrics.ObserveQuery("Clear")()
 	r.Status = models.Status{}
 	r.conn.Exec(context.Background(), DESTROY_DATABASE_DONT_TOCUH_DANGEROUS)
 }
+
+// GetThreadsPage returns up to limit threads of a forum strictly after the
+// key in (created, id) order, descending when desc is set, together with the
+// key of every thread. A nil key starts from the beginning.
+func (r *ForumRepository) GetThreadsPage(ctx context.Context, slug string, after *models.PageKey, desc bool, limit int) ([]models.Thread, []models.PageKey, error) {
+	defer metrics.ObserveQuery("GetThreadsPage")()
+	var rows pgx.Rows
+	var err error
+	switch {
+	case after == nil && !desc:
+		rows, err = r.conn.Query(ctx, PageThreadsAsc, slug, limit)
+	case after == nil && desc:
+		rows, err = r.conn.Query(ctx, PageThreadsDesc, slug, limit)
+	case !desc:
+		rows, err = r.conn.Query(ctx, PageThreadsAfterAsc, slug, after.Created, after.ID, limit)
+	default:
+		rows, err = r.conn.Query(ctx, PageThreadsAfterDesc, slug, after.Created, after.ID, limit)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	threads := make([]models.Thread, 0, limit)
+	keys := make([]models.PageKey, 0, limit)
+	for rows.Next() {
+		thread := models.Thread{}
+		err = rows.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum, &thread.Message,
+			&thread.Votes, &thread.Slug, &thread.Created, &thread.Version)
+		if err != nil {
+			return nil, nil, err
+		}
+		threads = append(threads, thread)
+		keys = append(keys, models.PageKey{Created: thread.Created, ID: thread.ID})
+	}
+	return threads, keys, rows.Err()
+}
+
+// GetThreadPostsPage returns the posts of a thread strictly after the key in
+// the order of sort. For flat and tree limit counts posts; for parent_tree it
+// counts root posts and every post is keyed by its root, so the caller pages
+// whole subtrees. Within a subtree posts stay in path order even when desc
+// is set, like in v1.
+func (r *ForumRepository) GetThreadPostsPage(ctx context.Context, threadId int, sort string, after *models.PageKey, desc bool, limit int) ([]models.Post, []models.PageKey, error) {
+	defer metrics.ObserveQuery("GetThreadPostsPage")()
+	var query string
+	var position interface{}
+	switch sort {
+	case "tree":
+		query = PagePostsTreeAsc
+		if desc {
+			query = PagePostsTreeDesc
+		}
+		// A path always sorts after the empty array and before the maximal one.
+		position = []int64{}
+		if desc {
+			position = []int64{math.MaxInt32}
+		}
+		if after != nil {
+			position = after.Path
+		}
+	case "parent_tree":
+		query = PagePostsParentTreeAsc
+		if desc {
+			query = PagePostsParentTreeDesc
+		}
+		position = 0
+		if desc {
+			position = math.MaxInt32
+		}
+		if after != nil {
+			position = after.ID
+		}
+	default:
+		query = PagePostsFlatAsc
+		if desc {
+			query = PagePostsFlatDesc
+		}
+		position = 0
+		if desc {
+			position = math.MaxInt32
+		}
+		if after != nil {
+			position = after.ID
+		}
+	}
+
+	rows, err := r.conn.Query(ctx, query, threadId, position, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]models.Post, 0, limit)
+	keys := make([]models.PageKey, 0, limit)
+	for rows.Next() {
+		post := models.Post{}
+		var path []int64
+		err = rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message,
+			&post.Parent, &post.Thread, &post.Version, &path)
+		if err != nil {
+			return nil, nil, err
+		}
+		posts = append(posts, post)
+		switch sort {
+		case "tree":
+			keys = append(keys, models.PageKey{Path: path})
+		case "parent_tree":
+			keys = append(keys, models.PageKey{ID: int(path[0])})
+		default:
+			keys = append(keys, models.PageKey{ID: post.ID})
+		}
+	}
+	return posts, keys, rows.Err()
+}
+
+// GetUsersPage returns up to limit users of a forum strictly after the key
+// in nickname order.
+func (r *ForumRepository) GetUsersPage(ctx context.Context, slug string, after *models.PageKey, desc bool, limit int) ([]models.User, []models.PageKey, error) {
+	defer metrics.ObserveQuery("GetUsersPage")()
+	var rows pgx.Rows
+	var err error
+	switch {
+	case after == nil && !desc:
+		rows, err = r.conn.Query(ctx, PageUsersFirstAsc, slug, limit)
+	case after == nil && desc:
+		rows, err = r.conn.Query(ctx, PageUsersFirstDesc, slug, limit)
+	case !desc:
+		rows, err = r.conn.Query(ctx, PageUsersAsc, slug, after.Nickname, limit)
+	default:
+		rows, err = r.conn.Query(ctx, PageUsersDesc, slug, after.Nickname, limit)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, limit)
+	keys := make([]models.PageKey, 0, limit)
+	for rows.Next() {
+		user := models.User{}
+		err = rows.Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email)
+		if err != nil {
+			return nil, nil, err
+		}
+		users = append(users, user)
+		keys = append(keys, models.PageKey{Nickname: user.Nickname})
+	}
+	return users, keys, rows.Err()
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+)
+
+const (
+	GetRoles = `SELECT u.isadmin,
+		EXISTS (SELECT 1 FROM forum WHERE slug = $2 AND "user" = u.nickname),
+		EXISTS (SELECT 1 FROM forum_moderator WHERE forum = $2 AND nickname = u.nickname)
+		FROM "user" u WHERE u.nickname = $1`
+	SetAdmin        = `UPDATE "user" SET isadmin = $2 WHERE nickname = $1`
+	GetModerators   = `SELECT u.nickname, u.fullname, u.about, u.email FROM forum_moderator m JOIN "user" u ON u.nickname = m.nickname WHERE m.forum = $1 ORDER BY u.nickname`
+	AddModerator    = `INSERT INTO forum_moderator (forum, nickname) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	RemoveModerator = `DELETE FROM forum_moderator WHERE forum = $1 AND nickname = $2`
+)
+
+// GetRoles returns the roles a user holds towards a forum, none when the
+// user does not exist.
+func (r *ForumRepository) GetRoles(ctx context.Context, nickname, forum string) (auth.Role, error) {
+	defer metrics.ObserveQuery("GetRoles")()
+	var admin, owner, moderator bool
+	err := r.conn.QueryRow(ctx, GetRoles, nickname, forum).Scan(&admin, &owner, &moderator)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	role := auth.RoleMember
+	if admin {
+		role |= auth.RoleAdmin
+	}
+	if owner {
+		role |= auth.RoleOwner
+	}
+	if moderator {
+		role |= auth.RoleModerator
+	}
+	return role, nil
+}
+
+func (r *ForumRepository) SetAdmin(ctx context.Context, nickname string, admin bool) error {
+	defer metrics.ObserveQuery("SetAdmin")()
+	tag, err := r.conn.Exec(ctx, SetAdmin, nickname, admin)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *ForumRepository) GetModerators(ctx context.Context, forum string) ([]models.User, error) {
+	defer metrics.ObserveQuery("GetModerators")()
+	rows, err := r.conn.Query(ctx, GetModerators, forum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		user := models.User{}
+		if err = rows.Scan(&user.Nickname, &user.Fullname, &user.About, &user.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// AddModerator returns models.ErrorNotFound when the forum or the user does
+// not exist. Adding a moderator twice is not an error.
+func (r *ForumRepository) AddModerator(ctx context.Context, forum, nickname string) error {
+	defer metrics.ObserveQuery("AddModerator")()
+	_, err := r.conn.Exec(ctx, AddModerator, forum, nickname)
+	if pqError, ok := err.(*pgconn.PgError); ok && pqError.Code == ForeingKeyError {
+		return models.ErrorNotFound
+	}
+	return err
+}
+
+func (r *ForumRepository) RemoveModerator(ctx context.Context, forum, nickname string) error {
+	defer metrics.ObserveQuery("RemoveModerator")()
+	tag, err := r.conn.Exec(ctx, RemoveModerator, forum, nickname)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
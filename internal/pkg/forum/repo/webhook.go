@@ -0,0 +1,219 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/webhooks"
+	"time"
+)
+
+const (
+	webhookColumns  = `id, forum, url, events, active, created`
+	deliveryColumns = `id, webhook, event, payload, status, attempts, CASE WHEN status = 'pending' THEN nextattempt END, coalesce(laststatus, 0), coalesce(lasterror, ''), created, delivered`
+
+	CreateWebhook = `INSERT INTO webhook (forum, url, secret, events, active) SELECT slug, $2, $3, $4, $5 FROM forum WHERE slug = $1 RETURNING ` + webhookColumns
+	GetWebhooks   = `SELECT ` + webhookColumns + ` FROM webhook WHERE forum = $1 ORDER BY id`
+	GetWebhook    = `SELECT ` + webhookColumns + ` FROM webhook WHERE forum = $1 AND id = $2`
+	UpdateWebhook = `UPDATE webhook SET url = coalesce(nullif($3, ''), url), events = coalesce($4, events), secret = coalesce(nullif($5, ''), secret), active = coalesce($6, active) WHERE forum = $1 AND id = $2 RETURNING ` + webhookColumns
+	DeleteWebhook = `DELETE FROM webhook WHERE forum = $1 AND id = $2`
+
+	GetWebhookSubscriptions = `SELECT forum, events FROM webhook WHERE active`
+	EnqueueWebhookDelivery  = `INSERT INTO webhook_delivery (webhook, event, payload) SELECT id, $2, $3 FROM webhook WHERE forum = $1 AND active AND $2 = ANY (events)`
+
+	GetWebhookDeliveriesAsc  = `SELECT ` + deliveryColumns + ` FROM webhook_delivery WHERE webhook = $1 AND ($2 = '' OR status = $2) AND id > $3 ORDER BY id LIMIT $4`
+	GetWebhookDeliveriesDesc = `SELECT ` + deliveryColumns + ` FROM webhook_delivery WHERE webhook = $1 AND ($2 = '' OR status = $2) AND ($3 = 0 OR id < $3) ORDER BY id DESC LIMIT $4`
+	RetryWebhookDelivery     = `UPDATE webhook_delivery SET status = 'pending', attempts = 0, nextattempt = now(), delivered = NULL WHERE webhook = $1 AND id = $2 RETURNING ` + deliveryColumns
+
+	// ClaimWebhookDeliveries takes due deliveries of active webhooks and
+	// moves them out of reach of other instances for the lease given in
+	// seconds.
+	ClaimWebhookDeliveries = `WITH claimed AS (
+		UPDATE webhook_delivery SET nextattempt = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT d.id FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook
+			WHERE d.status = 'pending' AND d.nextattempt <= now() AND w.active
+			ORDER BY d.nextattempt LIMIT $1 FOR UPDATE OF d SKIP LOCKED)
+		RETURNING id, webhook, event, payload, attempts, created)
+		SELECT c.id, c.webhook, c.event, c.payload, c.attempts, c.created, w.url, w.secret FROM claimed c JOIN webhook w ON w.id = c.webhook`
+	CompleteWebhookDelivery = `UPDATE webhook_delivery SET status = $2, attempts = attempts + 1, laststatus = nullif($3, 0), lasterror = nullif($4, ''), nextattempt = $5, delivered = CASE WHEN $2 = 'delivered' THEN now() END WHERE id = $1`
+	PurgeWebhookDeliveries  = `DELETE FROM webhook_delivery WHERE status <> 'pending' AND created < $1`
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebhook(row scanner) (models.Webhook, error) {
+	var webhook models.Webhook
+	err := row.Scan(&webhook.ID, &webhook.Forum, &webhook.URL, &webhook.Events, &webhook.Active, &webhook.Created)
+	return webhook, err
+}
+
+func scanDelivery(row scanner) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := row.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&delivery.NextAttempt, &delivery.LastStatus, &delivery.LastError, &delivery.Created, &delivery.Delivered)
+	return delivery, err
+}
+
+// notFound turns a missing row into models.ErrorNotFound.
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrorNotFound
+	}
+	return err
+}
+
+func (r *ForumRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	defer metrics.ObserveQuery("CreateWebhook")()
+	result, err := scanWebhook(r.conn.QueryRow(ctx, CreateWebhook, webhook.Forum, webhook.URL, webhook.Secret, webhook.Events, webhook.Active))
+	if err != nil {
+		return models.Webhook{}, notFound(err)
+	}
+	result.Secret = webhook.Secret
+	return result, nil
+}
+
+func (r *ForumRepository) GetWebhooks(ctx context.Context, forum string) ([]models.Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhooks")()
+	rows, err := r.conn.Query(ctx, GetWebhooks, forum)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]models.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *ForumRepository) GetWebhook(ctx context.Context, forum string, id int) (models.Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhook")()
+	webhook, err := scanWebhook(r.conn.QueryRow(ctx, GetWebhook, forum, id))
+	return webhook, notFound(err)
+}
+
+func (r *ForumRepository) UpdateWebhook(ctx context.Context, update models.WebhookUpdate) (models.Webhook, error) {
+	defer metrics.ObserveQuery("UpdateWebhook")()
+	webhook, err := scanWebhook(r.conn.QueryRow(ctx, UpdateWebhook, update.Forum, update.ID, update.URL, update.Events, update.Secret, update.Active))
+	return webhook, notFound(err)
+}
+
+func (r *ForumRepository) DeleteWebhook(ctx context.Context, forum string, id int) error {
+	defer metrics.ObserveQuery("DeleteWebhook")()
+	tag, err := r.conn.Exec(ctx, DeleteWebhook, forum, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrorNotFound
+	}
+	return nil
+}
+
+// GetWebhookSubscriptions returns the forum and events of every active
+// webhook.
+func (r *ForumRepository) GetWebhookSubscriptions(ctx context.Context) ([]models.Webhook, error) {
+	defer metrics.ObserveQuery("GetWebhookSubscriptions")()
+	rows, err := r.conn.Query(ctx, GetWebhookSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var webhook models.Webhook
+		if err = rows.Scan(&webhook.Forum, &webhook.Events); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// EnqueueWebhookDeliveries queues payload for every active webhook of
+// forum subscribed to event.
+func (r *ForumRepository) EnqueueWebhookDeliveries(ctx context.Context, forum, event string, payload []byte) error {
+	defer metrics.ObserveQuery("EnqueueWebhookDeliveries")()
+	_, err := r.conn.Exec(ctx, EnqueueWebhookDelivery, forum, event, payload)
+	return err
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook after the given
+// id, newest first when desc is set. An empty status lists all of them.
+func (r *ForumRepository) GetWebhookDeliveries(ctx context.Context, webhook int, status string, since int64, desc bool, limit int) ([]models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("GetWebhookDeliveries")()
+	query := GetWebhookDeliveriesAsc
+	if desc {
+		query = GetWebhookDeliveriesDesc
+	}
+	rows, err := r.conn.Query(ctx, query, webhook, status, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryWebhookDelivery queues a delivery again with a fresh set of
+// attempts, typically one that was dead-lettered.
+func (r *ForumRepository) RetryWebhookDelivery(ctx context.Context, webhook int, id int64) (models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("RetryWebhookDelivery")()
+	delivery, err := scanDelivery(r.conn.QueryRow(ctx, RetryWebhookDelivery, webhook, id))
+	return delivery, notFound(err)
+}
+
+func (r *ForumRepository) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhooks.Job, error) {
+	defer metrics.ObserveQuery("ClaimWebhookDeliveries")()
+	rows, err := r.conn.Query(ctx, ClaimWebhookDeliveries, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []webhooks.Job
+	for rows.Next() {
+		var job webhooks.Job
+		if err = rows.Scan(&job.ID, &job.Webhook, &job.Event, &job.Payload, &job.Attempts, &job.Created, &job.URL, &job.Secret); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *ForumRepository) CompleteWebhookDelivery(ctx context.Context, id int64, result webhooks.Result) error {
+	defer metrics.ObserveQuery("CompleteWebhookDelivery")()
+	_, err := r.conn.Exec(ctx, CompleteWebhookDelivery, id, result.Status, result.LastStatus, result.LastError, result.NextAttempt)
+	return err
+}
+
+// PurgeWebhookDeliveries deletes the finished deliveries created before
+// the given time.
+func (r *ForumRepository) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("PurgeWebhookDeliveries")()
+	tag, err := r.conn.Exec(ctx, PurgeWebhookDeliveries, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
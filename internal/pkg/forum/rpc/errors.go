@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"log"
+	"net/http"
+)
+
+// statusError maps a usecase error the way the REST layer does: the gRPC
+// code follows the HTTP status utils.NewError picks and the REST error body
+// is the first status detail. extra details follow it, such as the entity
+// a create conflicted with.
+func statusError(err error, resource, id string, extra ...protoiface.MessageV1) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	httpStatus, body := utils.NewError(err, resource, id)
+	if httpStatus == http.StatusInternalServerError {
+		log.Printf("grpc %s %q: %v", resource, id, err)
+	}
+	return newStatus(httpStatus, body, extra...)
+}
+
+func newStatus(httpStatus int, body models.Error, extra ...protoiface.MessageV1) error {
+	st := status.New(code(httpStatus), body.Message)
+	details := append([]protoiface.MessageV1{pb.FromError(body)}, extra...)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// The interceptors play the part of the AccessLog and Recover middlewares
+// of the HTTP server.
+
+func unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recovery(info.FullMethod, recovered)
+		}
+		accessLog(ctx, info.FullMethod, start, err)
+	}()
+	return handler(ctx, req)
+}
+
+func streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = recovery(info.FullMethod, recovered)
+		}
+		accessLog(ss.Context(), info.FullMethod, start, err)
+	}()
+	return handler(srv, ss)
+}
+
+func recovery(method string, recovered interface{}) error {
+	log.Printf("panic grpc method=%s: %v\n%s", method, recovered, debug.Stack())
+	return status.Error(codes.Internal, "Internal server error")
+}
+
+func accessLog(ctx context.Context, method string, start time.Time, err error) {
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	log.Printf("access grpc method=%s code=%s duration_ms=%.3f remote=%s",
+		method, status.Code(err), float64(time.Since(start).Microseconds())/1000, remote)
+}
+
+// methodScopes are the scopes an API key needs for each method, as the
+// scoped routes of the HTTP server. Methods not listed need every scope.
+var methodScopes = map[string]auth.Scope{
+	pb.ForumService_GetUser_FullMethodName:             auth.ScopeRead,
+	pb.ForumService_CreateUser_FullMethodName:          auth.ScopePost,
+	pb.ForumService_UpdateUser_FullMethodName:          auth.ScopePost,
+	pb.ForumService_GetUsers_FullMethodName:            auth.ScopeRead,
+	pb.ForumService_CreateForum_FullMethodName:         auth.ScopePost,
+	pb.ForumService_GetForum_FullMethodName:            auth.ScopeRead,
+	pb.ForumService_CreateThread_FullMethodName:        auth.ScopePost,
+	pb.ForumService_UpdateThread_FullMethodName:        auth.ScopePost,
+	pb.ForumService_GetThreads_FullMethodName:          auth.ScopeRead,
+	pb.ForumService_GetThread_FullMethodName:           auth.ScopeRead,
+	pb.ForumService_CreatePosts_FullMethodName:         auth.ScopePost,
+	pb.ForumService_Vote_FullMethodName:                auth.ScopeVote,
+	pb.ForumService_GetPost_FullMethodName:             auth.ScopeRead,
+	pb.ForumService_GetThreadPosts_FullMethodName:      auth.ScopeRead,
+	pb.ForumService_UpdatePost_FullMethodName:          auth.ScopePost,
+	pb.ForumService_GetThreadsPage_FullMethodName:      auth.ScopeRead,
+	pb.ForumService_GetThreadPostsPage_FullMethodName:  auth.ScopeRead,
+	pb.ForumService_GetUsersPage_FullMethodName:        auth.ScopeRead,
+	pb.ForumService_GetUsersByNicknames_FullMethodName: auth.ScopeRead,
+	pb.ForumService_GetForumsBySlugs_FullMethodName:    auth.ScopeRead,
+	pb.ForumService_GetThreadsByIds_FullMethodName:     auth.ScopeRead,
+	pb.ForumService_GetStatus_FullMethodName:           auth.ScopeRead,
+	pb.ForumService_Clear_FullMethodName:               auth.ScopeAdmin,
+}
+
+// authenticator resolves the bearer token in the authorization metadata
+// and checks its scope for the method, the way the Authenticate and
+// RequireScope middlewares do for HTTP.
+type authenticator func(ctx context.Context, token string) (auth.Principal, error)
+
+func (a authenticator) context(ctx context.Context, method string) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	scheme, token, _ := strings.Cut(values[0], " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, statusError(fmt.Errorf("%w: authorization must be a Bearer token", models.ErrorUnauthorized), "", "")
+	}
+	principal, err := a(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return nil, statusError(err, "", "")
+	}
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = auth.AllScopes
+	}
+	if !principal.Can(scope) {
+		return nil, statusError(fmt.Errorf("%w: API key lacks the %s scope", models.ErrorForbidden, scope), "", "")
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
+func (a authenticator) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.context(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.context(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,321 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Server implements pb.ForumServiceServer on top of ForumUsecase with the
+// semantics of the REST handlers.
+type Server struct {
+	pb.UnimplementedForumServiceServer
+	uc  forum.ForumUsecase
+	cfg *config.Config
+}
+
+func NewServer(forumUsecase forum.ForumUsecase, cfg *config.Config) *Server {
+	return &Server{
+		uc:  forumUsecase,
+		cfg: cfg,
+	}
+}
+
+// NewGRPCServer returns a gRPC server with the forum service registered.
+func NewGRPCServer(forumUsecase forum.ForumUsecase, cfg *config.Config) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{unaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{streamInterceptor}
+	if cfg.Auth.Enabled {
+		authenticate := authenticator(forumUsecase.Authenticate)
+		unary = append(unary, authenticate.unary)
+		stream = append(stream, authenticate.stream)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	pb.RegisterForumServiceServer(server, NewServer(forumUsecase, cfg))
+	return server
+}
+
+func (s *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := s.uc.GetUser(ctx, req.GetNickname())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceUser, req.GetNickname())
+	}
+	return pb.FromUser(user), nil
+}
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.User) (*pb.User, error) {
+	result, err := s.uc.CreateUser(ctx, req.Model())
+	if errors.Is(err, models.ErrorConflict) {
+		return nil, statusError(err, utils.ResourceUser, req.GetNickname(), pb.FromUsers(result))
+	} else if err != nil {
+		return nil, statusError(err, utils.ResourceUser, req.GetNickname())
+	}
+	return pb.FromUser(result[0]), nil
+}
+
+func (s *Server) UpdateUser(ctx context.Context, req *pb.User) (*pb.User, error) {
+	user, err := s.uc.UpdateUser(ctx, req.Model())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceUser, req.GetNickname())
+	}
+	return pb.FromUser(user), nil
+}
+
+func (s *Server) GetUsers(ctx context.Context, req *pb.GetUsersRequest) (*pb.UserList, error) {
+	users, err := s.uc.GetUsers(ctx, req.GetSlug(), limit(req.GetLimit()), req.GetSince(), desc(req.GetDesc()))
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug())
+	}
+	return pb.FromUsers(users), nil
+}
+
+func (s *Server) CreateForum(ctx context.Context, req *pb.Forum) (*pb.Forum, error) {
+	result, err := s.uc.CreateForum(ctx, req.Model())
+	if errors.Is(err, models.ErrorConflict) {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug(), pb.FromForum(result))
+	} else if err != nil {
+		return nil, statusError(err, utils.ResourceUser, req.GetUser())
+	}
+	return pb.FromForum(result), nil
+}
+
+func (s *Server) GetForum(ctx context.Context, req *pb.GetForumRequest) (*pb.Forum, error) {
+	result, err := s.uc.GetForum(ctx, req.GetSlug())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug())
+	}
+	return pb.FromForum(result), nil
+}
+
+func (s *Server) CreateThread(ctx context.Context, req *pb.Thread) (*pb.Thread, error) {
+	result, err := s.uc.CreateThread(ctx, req.Model())
+	if errors.Is(err, models.ErrorConflict) {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlug(), pb.FromThread(result))
+	} else if errors.Is(err, models.ErrorNotFound) {
+		// The repository does not tell a missing author from a missing forum.
+		_, body := utils.NewError(err, utils.ResourceForum, req.GetForum())
+		body.Message = fmt.Sprintf("Can't find forum %q or user %q", req.GetForum(), req.GetAuthor())
+		return nil, newStatus(http.StatusNotFound, body)
+	} else if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlug())
+	}
+	return pb.FromThread(result), nil
+}
+
+func (s *Server) UpdateThread(ctx context.Context, req *pb.UpdateThreadRequest) (*pb.Thread, error) {
+	thread := models.Thread{Title: req.GetTitle(), Message: req.GetMessage(), Version: int(req.GetVersion())}
+	if id, err := strconv.Atoi(req.GetSlugOrId()); err != nil {
+		thread.Slug = req.GetSlugOrId()
+	} else {
+		thread.ID = id
+	}
+	result, err := s.uc.UpdateThread(ctx, thread)
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	return pb.FromThread(result), nil
+}
+
+func (s *Server) GetThreads(ctx context.Context, req *pb.GetThreadsRequest) (*pb.ThreadList, error) {
+	since := ""
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime().Format(time.RFC3339Nano)
+	}
+	threads, err := s.uc.GetThreads(ctx, req.GetSlug(), limit(req.GetLimit()), since, desc(req.GetDesc()))
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug())
+	}
+	return pb.FromThreads(threads), nil
+}
+
+func (s *Server) GetThread(ctx context.Context, req *pb.GetThreadRequest) (*pb.Thread, error) {
+	thread, err := s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	return pb.FromThread(thread), nil
+}
+
+func (s *Server) CreatePosts(ctx context.Context, req *pb.CreatePostsRequest) (*pb.PostList, error) {
+	thread, err := s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	posts := (&pb.PostList{Items: req.GetPosts()}).Model()
+	if len(posts) == 0 {
+		return pb.FromPosts(posts), nil
+	}
+
+	created, err := s.uc.CreatePosts(ctx, posts, thread)
+	if errors.Is(err, models.ErrorNotFound) {
+		_, body := utils.NewError(err, utils.ResourceUser, "")
+		body.Message = "Can't find post author"
+		return nil, newStatus(http.StatusNotFound, body)
+	} else if errors.Is(err, models.ErrorConflict) {
+		_, body := utils.NewError(err, utils.ResourcePost, "")
+		body.Message = "Parent post was created in another thread"
+		return nil, newStatus(http.StatusConflict, body)
+	} else if err != nil {
+		return nil, statusError(err, utils.ResourcePost, "")
+	}
+	return pb.FromPosts(created), nil
+}
+
+func (s *Server) Vote(ctx context.Context, req *pb.VoteRequest) (*pb.Thread, error) {
+	thread, err := s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	vote := req.GetVote().Model()
+	vote.Thread = thread.ID
+	if err = s.uc.Vote(ctx, vote); err != nil {
+		return nil, statusError(err, utils.ResourceUser, vote.Nickname)
+	}
+	thread, err = s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	return pb.FromThread(thread), nil
+}
+
+func (s *Server) GetPost(ctx context.Context, req *pb.GetPostRequest) (*pb.PostFull, error) {
+	id := strconv.FormatInt(req.GetId(), 10)
+	result, err := s.uc.GetPost(ctx, id, req.GetRelated())
+	if err != nil {
+		return nil, statusError(err, utils.ResourcePost, id)
+	}
+	return pb.FromPostFull(result), nil
+}
+
+func (s *Server) GetThreadPosts(req *pb.GetThreadPostsRequest, stream pb.ForumService_GetThreadPostsServer) error {
+	ctx := stream.Context()
+	thread, err := s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+
+	since := ""
+	if req.GetSince() != 0 {
+		since = strconv.FormatInt(req.GetSince(), 10)
+	}
+	err = s.uc.StreamThreadPosts(ctx, limit(req.GetLimit()), since, desc(req.GetDesc()), req.GetSort(), thread.ID, nil, func(post models.Post) error {
+		return stream.Send(pb.FromPost(post))
+	})
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		// Send failed, its error already is a status.
+		return err
+	}
+	return statusError(err, utils.ResourceThread, req.GetSlugOrId())
+}
+
+func (s *Server) UpdatePost(ctx context.Context, req *pb.UpdatePostRequest) (*pb.Post, error) {
+	update := models.PostUpdate{ID: int(req.GetId()), Message: req.GetMessage(), Version: int(req.GetVersion())}
+	result, err := s.uc.UpdatePost(ctx, update)
+	if err != nil {
+		return nil, statusError(err, utils.ResourcePost, strconv.FormatInt(req.GetId(), 10))
+	}
+	return pb.FromPost(result), nil
+}
+
+func (s *Server) GetThreadsPage(ctx context.Context, req *pb.GetThreadsPageRequest) (*pb.ThreadPage, error) {
+	result, err := s.uc.GetThreadsPage(ctx, req.GetSlug(), limit(req.GetLimit()), req.GetCursor(), desc(req.GetDesc()))
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug())
+	}
+	return pb.FromThreadsPage(result), nil
+}
+
+func (s *Server) GetThreadPostsPage(ctx context.Context, req *pb.GetThreadPostsPageRequest) (*pb.PostPage, error) {
+	thread, err := s.uc.CheckThreadByIdOrSlug(ctx, req.GetSlugOrId())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	result, err := s.uc.GetThreadPostsPage(ctx, limit(req.GetLimit()), req.GetCursor(), desc(req.GetDesc()), req.GetSort(), thread.ID)
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, req.GetSlugOrId())
+	}
+	return pb.FromPostsPage(result), nil
+}
+
+func (s *Server) GetUsersPage(ctx context.Context, req *pb.GetUsersPageRequest) (*pb.UserPage, error) {
+	result, err := s.uc.GetUsersPage(ctx, req.GetSlug(), limit(req.GetLimit()), req.GetCursor(), desc(req.GetDesc()))
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, req.GetSlug())
+	}
+	return pb.FromUsersPage(result), nil
+}
+
+func (s *Server) GetUsersByNicknames(ctx context.Context, req *pb.GetUsersByNicknamesRequest) (*pb.UserList, error) {
+	users, err := s.uc.GetUsersByNicknames(ctx, req.GetNicknames())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceUser, "")
+	}
+	return pb.FromUsers(users), nil
+}
+
+func (s *Server) GetForumsBySlugs(ctx context.Context, req *pb.GetForumsBySlugsRequest) (*pb.ForumList, error) {
+	forums, err := s.uc.GetForumsBySlugs(ctx, req.GetSlugs())
+	if err != nil {
+		return nil, statusError(err, utils.ResourceForum, "")
+	}
+	return pb.FromForums(forums), nil
+}
+
+func (s *Server) GetThreadsByIds(ctx context.Context, req *pb.GetThreadsByIdsRequest) (*pb.ThreadList, error) {
+	ids := make([]int, 0, len(req.GetIds()))
+	for _, id := range req.GetIds() {
+		ids = append(ids, int(id))
+	}
+	threads, err := s.uc.GetThreadsByIds(ctx, ids)
+	if err != nil {
+		return nil, statusError(err, utils.ResourceThread, "")
+	}
+	return pb.FromThreads(threads), nil
+}
+
+func (s *Server) GetStatus(context.Context, *emptypb.Empty) (*pb.Status, error) {
+	return pb.FromStatus(s.uc.GetStatus()), nil
+}
+
+func (s *Server) Clear(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if !s.cfg.Features.AllowClear {
+		return nil, newStatus(http.StatusForbidden, models.Error{Code: models.CodeForbidden, Message: "Clear is disabled"})
+	}
+	if err := s.uc.Clear(ctx); err != nil {
+		return nil, statusError(err, "", "")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// limit and desc turn the proto zero values into the absent query
+// parameters of the REST API.
+func limit(n int32) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(n))
+}
+
+func desc(d bool) string {
+	if !d {
+		return ""
+	}
+	return "true"
+}
@@ -0,0 +1,334 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	handler "github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum/delivery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var created = time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+// stubUsecase answers from fixed data; every method the tests do not
+// override panics through the nil embedded interface.
+type stubUsecase struct {
+	forum.ForumUsecase
+	users   map[string]models.User
+	threads map[string]models.Thread
+	posts   models.PostsList
+	// err, when set, is what every write returns.
+	err error
+}
+
+func (s *stubUsecase) GetUser(ctx context.Context, nickname string) (models.User, error) {
+	user, ok := s.users[nickname]
+	if !ok {
+		return models.User{}, models.ErrorNotFound
+	}
+	return user, nil
+}
+
+func (s *stubUsecase) CreateUser(ctx context.Context, user models.User) ([]models.User, error) {
+	if existing, ok := s.users[user.Nickname]; ok {
+		return []models.User{existing}, models.ErrorConflict
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []models.User{user}, nil
+}
+
+func (s *stubUsecase) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	if s.err != nil {
+		return models.User{}, s.err
+	}
+	return user, nil
+}
+
+func (s *stubUsecase) CheckThreadByIdOrSlug(ctx context.Context, slugOrId string) (models.Thread, error) {
+	thread, ok := s.threads[slugOrId]
+	if !ok {
+		return models.Thread{}, models.ErrorNotFound
+	}
+	return thread, nil
+}
+
+func (s *stubUsecase) UpdateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	if s.err != nil {
+		return models.Thread{}, s.err
+	}
+	return thread, nil
+}
+
+func (s *stubUsecase) StreamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error {
+	for _, post := range s.posts {
+		if post.Thread != threadId {
+			continue
+		}
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newStub() *stubUsecase {
+	thread := models.Thread{ID: 42, Title: "Davy Jones cache", Author: "j.sparrow", Forum: "pirate-stories", Message: "m", Slug: "jones", Created: created}
+	stub := &stubUsecase{
+		users: map[string]models.User{
+			"j.sparrow": {Nickname: "j.sparrow", Fullname: "Jack Sparrow", Email: "captain@blackpearl.sea", Version: 1},
+		},
+		threads: map[string]models.Thread{"42": thread, "jones": thread},
+	}
+	for i := 1; i <= 5; i++ {
+		stub.posts = append(stub.posts, models.Post{ID: i, Parent: i / 2, Author: "j.sparrow", Message: fmt.Sprintf("post %d", i), Forum: "pirate-stories", Thread: 42, Created: created})
+	}
+	stub.posts = append(stub.posts, models.Post{ID: 6, Author: "j.sparrow", Message: "elsewhere", Forum: "pirate-stories", Thread: 7, Created: created})
+	return stub
+}
+
+// dial serves the forum service for uc over an in-memory listener and
+// returns a client for it.
+func dial(t *testing.T, uc forum.ForumUsecase) pb.ForumServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(uc, config.Default())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewForumServiceClient(conn)
+}
+
+// rest runs a REST handler for the same usecase and returns the status and
+// the raw body.
+func rest(t *testing.T, serve http.HandlerFunc, method, target string, vars map[string]string, body string) (int, []byte) {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	serve(w, mux.SetURLVars(r, vars))
+	return w.Code, w.Body.Bytes()
+}
+
+func TestGetUser(t *testing.T) {
+	client := dial(t, newStub())
+	user, err := client.GetUser(context.Background(), &pb.GetUserRequest{Nickname: "j.sparrow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.User{Nickname: "j.sparrow", Fullname: "Jack Sparrow", Email: "captain@blackpearl.sea", Version: 1}
+	if got := user.Model(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGetThreadPosts(t *testing.T) {
+	stub := newStub()
+	client := dial(t, stub)
+
+	for _, slugOrId := range []string{"42", "jones"} {
+		t.Run(slugOrId, func(t *testing.T) {
+			stream, err := client.GetThreadPosts(context.Background(), &pb.GetThreadPostsRequest{SlugOrId: slugOrId, Limit: 100})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got models.PostsList
+			for {
+				post, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, post.Model())
+			}
+			if want := stub.posts[:5]; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("missing thread", func(t *testing.T) {
+		stream, err := client.GetThreadPosts(context.Background(), &pb.GetThreadPostsRequest{SlugOrId: "kraken"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = stream.Recv(); status.Code(err) != codes.NotFound {
+			t.Fatalf("got %v, want NotFound", err)
+		}
+	})
+}
+
+// TestErrorMapping checks each gRPC error against the response the REST
+// handler gives for the same usecase result: the code follows the HTTP
+// status and the first detail is the REST error body.
+func TestErrorMapping(t *testing.T) {
+	validation := &models.ValidationError{Fields: []models.FieldError{{Field: "email", Message: "must be an email address"}}}
+	forbidden := fmt.Errorf("%w: only the author, forum moderators and admins can edit a thread", models.ErrorForbidden)
+
+	cases := []struct {
+		name     string
+		err      error
+		code     codes.Code
+		httpCode int
+		call     func(client pb.ForumServiceClient) error
+		rest     func(t *testing.T, h *handler.Handler) (int, []byte)
+	}{
+		{
+			name:     "NotFound",
+			code:     codes.NotFound,
+			httpCode: http.StatusNotFound,
+			call: func(client pb.ForumServiceClient) error {
+				_, err := client.GetUser(context.Background(), &pb.GetUserRequest{Nickname: "w.turner"})
+				return err
+			},
+			rest: func(t *testing.T, h *handler.Handler) (int, []byte) {
+				return rest(t, h.GetUser, http.MethodGet, "/api/user/w.turner/profile", map[string]string{"nickname": "w.turner"}, "")
+			},
+		},
+		{
+			name:     "InvalidArgument",
+			err:      validation,
+			code:     codes.InvalidArgument,
+			httpCode: http.StatusBadRequest,
+			call: func(client pb.ForumServiceClient) error {
+				_, err := client.UpdateUser(context.Background(), &pb.User{Nickname: "j.sparrow", Email: "captain"})
+				return err
+			},
+			rest: func(t *testing.T, h *handler.Handler) (int, []byte) {
+				return rest(t, h.UpdateUser, http.MethodPost, "/api/user/j.sparrow/profile", map[string]string{"nickname": "j.sparrow"}, `{"email":"captain"}`)
+			},
+		},
+		{
+			name:     "PermissionDenied",
+			err:      forbidden,
+			code:     codes.PermissionDenied,
+			httpCode: http.StatusForbidden,
+			call: func(client pb.ForumServiceClient) error {
+				_, err := client.UpdateThread(context.Background(), &pb.UpdateThreadRequest{SlugOrId: "jones", Title: "Mine now"})
+				return err
+			},
+			rest: func(t *testing.T, h *handler.Handler) (int, []byte) {
+				return rest(t, h.UpdateThread, http.MethodPost, "/api/thread/jones/details", map[string]string{"slug_or_id": "jones"}, `{"title":"Mine now"}`)
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := newStub()
+			stub.err = tc.err
+			client := dial(t, stub)
+
+			err := tc.call(client)
+			st, ok := status.FromError(err)
+			if !ok || st.Code() != tc.code {
+				t.Fatalf("got %v, want %s", err, tc.code)
+			}
+			httpCode, body := tc.rest(t, handler.NewForumHandler(stub, config.Default()))
+			if httpCode != tc.httpCode {
+				t.Fatalf("REST status %d, want %d", httpCode, tc.httpCode)
+			}
+			var want models.Error
+			if err = json.Unmarshal(body, &want); err != nil {
+				t.Fatal(err)
+			}
+			details := st.Details()
+			if len(details) == 0 {
+				t.Fatal("status has no details")
+			}
+			got, ok := details[0].(*pb.Error)
+			if !ok {
+				t.Fatalf("first detail is %T, want *pb.Error", details[0])
+			}
+			if !reflect.DeepEqual(got.Model(), want) {
+				t.Errorf("detail %+v, REST body %+v", got.Model(), want)
+			}
+			if st.Message() != want.Message {
+				t.Errorf("message %q, REST message %q", st.Message(), want.Message)
+			}
+		})
+	}
+}
+
+// TestConflictMapping covers AlreadyExists: REST answers with the existing
+// users, gRPC carries them as the detail after the error body.
+func TestConflictMapping(t *testing.T) {
+	stub := newStub()
+	client := dial(t, stub)
+
+	_, err := client.CreateUser(context.Background(), &pb.User{Nickname: "j.sparrow", Fullname: "Jack", Email: "jack@example.com"})
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.AlreadyExists {
+		t.Fatalf("got %v, want AlreadyExists", err)
+	}
+
+	httpCode, body := rest(t, handler.NewForumHandler(stub, config.Default()).CreateUser, http.MethodPost,
+		"/api/user/j.sparrow/create", map[string]string{"nickname": "j.sparrow"}, `{"fullname":"Jack","email":"jack@example.com"}`)
+	if httpCode != http.StatusConflict {
+		t.Fatalf("REST status %d, want %d", httpCode, http.StatusConflict)
+	}
+	var want []models.User
+	if err = json.Unmarshal(body, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	details := st.Details()
+	if len(details) != 2 {
+		t.Fatalf("got %d details, want the error and the existing users", len(details))
+	}
+	if body, ok := details[0].(*pb.Error); !ok || body.GetCode() != models.CodeConflict {
+		t.Errorf("first detail %v, want a %s error", details[0], models.CodeConflict)
+	}
+	existing, ok := details[1].(*pb.UserList)
+	if !ok {
+		t.Fatalf("second detail is %T, want *pb.UserList", details[1])
+	}
+	if got := existing.Model(); !reflect.DeepEqual(got, want) {
+		t.Errorf("existing users %+v, REST body %+v", got, want)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	err := statusError(fmt.Errorf("get user: %w", context.Canceled), "user", "j.sparrow")
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("got %v, want Canceled", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Error("the context error leaked through the status")
+	}
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"log"
+	"time"
+)
+
+// lastUsedResolution is how stale the last use of an API key may get, so a
+// busy bot does not write to the database on every request.
+const lastUsedResolution = time.Minute
+
+var errAPIKey = fmt.Errorf("%w: Unknown or revoked API key", models.ErrorUnauthorized)
+
+// CreateAPIKey issues a key acting as nickname. The key itself is only in
+// the result; afterwards only its hash is left.
+func (u *ForumUsecase) CreateAPIKey(ctx context.Context, nickname string, key models.APIKey) (models.APIKey, error) {
+	v := &validator{}
+	v.nickname("nickname", nickname)
+	scopes := v.apiKey(key)
+	if err := v.err(); err != nil {
+		return models.APIKey{}, err
+	}
+	if err := u.authorize(ctx, manageKeys, "", nickname); err != nil {
+		return models.APIKey{}, err
+	}
+
+	secret, hash := auth.NewAPIKey()
+	key.ID = auth.NewSessionID()
+	key.Nickname = nickname
+	key.Scopes = scopes.Names()
+	result, err := u.repo.CreateAPIKey(ctx, key, hash)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	result.Key = secret
+	return result, nil
+}
+
+func (u *ForumUsecase) GetAPIKeys(ctx context.Context, nickname string) ([]models.APIKey, error) {
+	v := &validator{}
+	v.nickname("nickname", nickname)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if err := u.authorize(ctx, manageKeys, "", nickname); err != nil {
+		return nil, err
+	}
+	return u.repo.GetAPIKeys(ctx, nickname)
+}
+
+// RevokeAPIKey deletes a key, which stops working right away.
+func (u *ForumUsecase) RevokeAPIKey(ctx context.Context, nickname, id string) error {
+	v := &validator{}
+	v.nickname("nickname", nickname)
+	v.required("id", id)
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := u.authorize(ctx, manageKeys, "", nickname); err != nil {
+		return err
+	}
+	return u.repo.DeleteAPIKey(ctx, nickname, id)
+}
+
+// authenticateKey returns the principal of an API key and records its use.
+func (u *ForumUsecase) authenticateKey(ctx context.Context, secret string) (auth.Principal, error) {
+	key, err := u.repo.GetAPIKeyByHash(ctx, auth.HashAPIKey(secret))
+	if errors.Is(err, models.ErrorNotFound) {
+		return auth.Principal{}, errAPIKey
+	} else if err != nil {
+		return auth.Principal{}, err
+	}
+	if key.LastUsed == nil || time.Since(*key.LastUsed) > lastUsedResolution {
+		if err = u.repo.TouchAPIKey(ctx, key.ID); err != nil {
+			log.Printf("record use of api key %s: %v", key.ID, err)
+		}
+	}
+
+	principal := auth.Principal{Nickname: key.Nickname, APIKey: key.ID}
+	for _, name := range key.Scopes {
+		scope, _ := auth.ParseScope(name)
+		principal.Scopes |= scope
+	}
+	return principal, nil
+}
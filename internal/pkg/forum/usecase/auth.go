@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"strings"
+	"time"
+)
+
+var (
+	errWrongCredentials = fmt.Errorf("%w: Wrong nickname or password", models.ErrorUnauthorized)
+	errRefreshToken     = fmt.Errorf("%w: Invalid or expired refresh token", models.ErrorUnauthorized)
+	errAccessToken      = fmt.Errorf("%w: Invalid access token", models.ErrorUnauthorized)
+	errExpiredToken     = fmt.Errorf("%w: Access token expired, refresh it", models.ErrorUnauthorized)
+)
+
+// Login checks the password of a user and opens a session.
+func (u *ForumUsecase) Login(ctx context.Context, credentials models.Credentials) (models.Session, error) {
+	v := &validator{}
+	if v.required("nickname", credentials.Nickname) {
+		v.nickname("nickname", credentials.Nickname)
+	}
+	v.required("password", credentials.Password)
+	if err := v.err(); err != nil {
+		return models.Session{}, err
+	}
+
+	nickname, hash, err := u.repo.GetUserCredentials(ctx, credentials.Nickname)
+	if err != nil && !errors.Is(err, models.ErrorNotFound) {
+		return models.Session{}, err
+	}
+	// Checked for unknown users too, so the response time does not tell
+	// which nicknames exist.
+	if !u.passwords.Check(hash, credentials.Password) {
+		return models.Session{}, errWrongCredentials
+	}
+
+	id := auth.NewSessionID()
+	refreshToken, refreshHash := auth.NewRefreshToken()
+	refreshExpires := time.Now().Add(u.cfg.Auth.RefreshTTL.Std())
+	if err = u.repo.CreateSession(ctx, id, nickname, refreshHash, refreshExpires); err != nil {
+		return models.Session{}, err
+	}
+	return u.session(id, nickname, refreshToken, refreshExpires), nil
+}
+
+// Refresh uses up a refresh token for a new token pair of its session.
+func (u *ForumUsecase) Refresh(ctx context.Context, request models.RefreshRequest) (models.Session, error) {
+	v := &validator{}
+	v.required("refresh_token", request.RefreshToken)
+	if err := v.err(); err != nil {
+		return models.Session{}, err
+	}
+
+	refreshToken, refreshHash := auth.NewRefreshToken()
+	refreshExpires := time.Now().Add(u.cfg.Auth.RefreshTTL.Std())
+	id, nickname, err := u.repo.RotateSession(ctx, auth.HashRefreshToken(request.RefreshToken), refreshHash, refreshExpires)
+	if errors.Is(err, models.ErrorNotFound) {
+		return models.Session{}, errRefreshToken
+	} else if err != nil {
+		return models.Session{}, err
+	}
+	return u.session(id, nickname, refreshToken, refreshExpires), nil
+}
+
+// Logout ends the session of the request, so its refresh token stops
+// working. Its access tokens stay valid until they expire.
+func (u *ForumUsecase) Logout(ctx context.Context) error {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.Session == "" {
+		return models.ErrorUnauthorized
+	}
+	return u.repo.DeleteSession(ctx, principal.Session)
+}
+
+// Authenticate returns the user an access token was issued to or an API
+// key acts as.
+func (u *ForumUsecase) Authenticate(ctx context.Context, token string) (auth.Principal, error) {
+	if strings.HasPrefix(token, auth.APIKeyPrefix) {
+		return u.authenticateKey(ctx, token)
+	}
+	principal, err := u.tokens.Verify(token)
+	switch {
+	case errors.Is(err, auth.ErrExpiredToken):
+		return auth.Principal{}, errExpiredToken
+	case err != nil:
+		return auth.Principal{}, errAccessToken
+	}
+	return principal, nil
+}
+
+func (u *ForumUsecase) session(id, nickname, refreshToken string, refreshExpires time.Time) models.Session {
+	accessToken, _ := u.tokens.Issue(auth.Principal{Nickname: nickname, Session: id})
+	return models.Session{
+		Nickname:       nickname,
+		AccessToken:    accessToken,
+		TokenType:      "Bearer",
+		ExpiresIn:      int(u.cfg.Auth.AccessTTL.Std().Seconds()),
+		RefreshToken:   refreshToken,
+		RefreshExpires: refreshExpires,
+	}
+}
+
+// actAs checks that the request may act as nickname, which with auth
+// enabled only the user itself and operators may.
+func (u *ForumUsecase) actAs(ctx context.Context, nickname string) error {
+	if !u.cfg.Auth.Enabled {
+		return nil
+	}
+	principal, ok := auth.FromContext(ctx)
+	switch {
+	case !ok:
+		return models.ErrorUnauthorized
+	case principal.Operator || strings.EqualFold(principal.Nickname, nickname):
+		return nil
+	}
+	return fmt.Errorf("%w: Signed in as %s, cannot act as %s", models.ErrorForbidden, principal.Nickname, nickname)
+}
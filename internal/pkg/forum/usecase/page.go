@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/cursor"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"strconv"
+	"strings"
+)
+
+// The /api/v2 listings page with signed cursors. The repository fetches one
+// unit more than asked in the direction of travel; a unit is a row, or a
+// whole subtree for parent_tree. Going backward it fetches in the reverse
+// order and the page is flipped back here.
+
+func (u *ForumUsecase) GetThreadsPage(ctx context.Context, slug, limit, cursorToken, desc string) (models.ThreadsPage, error) {
+	scope := strings.Join([]string{"threads", strings.ToLower(slug), order(desc)}, "/")
+	v := &validator{}
+	v.slug("slug", slug)
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.desc(desc)
+	current := v.cursor(u.signer, cursorToken, scope)
+	if err := v.err(); err != nil {
+		return models.ThreadsPage{}, err
+	}
+
+	if _, err := u.repo.GetForum(ctx, slug); errors.Is(err, models.ErrorNotFound) {
+		return models.ThreadsPage{}, err
+	}
+
+	size := u.pageSize(limit)
+	threads, keys, err := u.repo.GetThreadsPage(ctx, slug, position(current), fetchDesc(desc, current), size+1)
+	if err != nil {
+		return models.ThreadsPage{}, err
+	}
+	threads, keys, more := paginate(threads, keys, size, current)
+	page := models.ThreadsPage{Items: threads}
+	page.NextCursor, page.PrevCursor = u.pageCursors(scope, current, keys, more)
+	return page, nil
+}
+
+func (u *ForumUsecase) GetThreadPostsPage(ctx context.Context, limit, cursorToken, desc, sort string, threadId int) (models.PostsPage, error) {
+	if sort == "" {
+		sort = "flat"
+	}
+	scope := strings.Join([]string{"posts", strconv.Itoa(threadId), sort, order(desc)}, "/")
+	v := &validator{}
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.desc(desc)
+	v.sort(sort)
+	current := v.cursor(u.signer, cursorToken, scope)
+	if err := v.err(); err != nil {
+		return models.PostsPage{}, err
+	}
+
+	size := u.pageSize(limit)
+	posts, keys, err := u.repo.GetThreadPostsPage(ctx, threadId, sort, position(current), fetchDesc(desc, current), size+1)
+	if err != nil {
+		return models.PostsPage{}, err
+	}
+	posts, keys, more := paginate(posts, keys, size, current)
+	page := models.PostsPage{Items: posts}
+	page.NextCursor, page.PrevCursor = u.pageCursors(scope, current, keys, more)
+	return page, nil
+}
+
+func (u *ForumUsecase) GetUsersPage(ctx context.Context, slug, limit, cursorToken, desc string) (models.UsersPage, error) {
+	scope := strings.Join([]string{"users", strings.ToLower(slug), order(desc)}, "/")
+	v := &validator{}
+	v.slug("slug", slug)
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.desc(desc)
+	current := v.cursor(u.signer, cursorToken, scope)
+	if err := v.err(); err != nil {
+		return models.UsersPage{}, err
+	}
+
+	if _, err := u.repo.GetForum(ctx, slug); errors.Is(err, models.ErrorNotFound) {
+		return models.UsersPage{}, err
+	}
+
+	size := u.pageSize(limit)
+	users, keys, err := u.repo.GetUsersPage(ctx, slug, position(current), fetchDesc(desc, current), size+1)
+	if err != nil {
+		return models.UsersPage{}, err
+	}
+	users, keys, more := paginate(users, keys, size, current)
+	page := models.UsersPage{Items: users}
+	page.NextCursor, page.PrevCursor = u.pageCursors(scope, current, keys, more)
+	return page, nil
+}
+
+func (u *ForumUsecase) pageSize(limit string) int {
+	if size, err := strconv.Atoi(limit); err == nil {
+		return size
+	}
+	return u.cfg.Pagination.DefaultLimit
+}
+
+// pageCursors hands out a cursor for every direction that has rows: forward
+// after the last unit when more follow or when the page was reached going
+// backward, backward before the first unit when more precede or when the
+// page was reached going forward from a cursor.
+func (u *ForumUsecase) pageCursors(scope string, current *cursor.Cursor, keys []models.PageKey, more bool) (next, prev string) {
+	if len(keys) == 0 {
+		return "", ""
+	}
+	backward := current != nil && current.Backward
+	if more || backward {
+		next = u.signer.Encode(cursor.Cursor{Scope: scope, Key: keys[len(keys)-1]})
+	}
+	if more && backward || current != nil && !backward {
+		prev = u.signer.Encode(cursor.Cursor{Scope: scope, Backward: true, Key: keys[0]})
+	}
+	return next, prev
+}
+
+// paginate keeps the first size units of the fetched rows, reports whether
+// there were more and restores the listing order of a backward fetch.
+// Reversal keeps the rows of a unit in place, so a parent_tree subtree
+// stays in path order.
+func paginate[T any](items []T, keys []models.PageKey, size int, current *cursor.Cursor) ([]T, []models.PageKey, bool) {
+	var starts []int
+	for i := range keys {
+		if i == 0 || !keys[i].Equal(keys[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+	more := len(starts) > size
+	if more {
+		items, keys = items[:starts[size]], keys[:starts[size]]
+		starts = starts[:size]
+	}
+	if current == nil || !current.Backward {
+		return items, keys, more
+	}
+
+	reversedItems := make([]T, 0, len(items))
+	reversedKeys := make([]models.PageKey, 0, len(keys))
+	end := len(items)
+	for i := len(starts) - 1; i >= 0; i-- {
+		reversedItems = append(reversedItems, items[starts[i]:end]...)
+		reversedKeys = append(reversedKeys, keys[starts[i]:end]...)
+		end = starts[i]
+	}
+	return reversedItems, reversedKeys, more
+}
+
+func position(current *cursor.Cursor) *models.PageKey {
+	if current == nil {
+		return nil
+	}
+	return &current.Key
+}
+
+// fetchDesc is the order to query in: the listing order, or its reverse
+// when walking backward.
+func fetchDesc(desc string, current *cursor.Cursor) bool {
+	return (desc == "true") != (current != nil && current.Backward)
+}
+
+func order(desc string) string {
+	if desc == "true" {
+		return "desc"
+	}
+	return "asc"
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"strings"
+)
+
+// permission is an operation that needs more than acting as oneself.
+type permission int
+
+const (
+	editContent permission = iota
+	manageModerators
+	manageWebhooks
+	manageAdmins
+	manageKeys
+	clearData
+)
+
+// grant says who holds a permission: users with any of roles towards the
+// forum acted on, as long as their credentials hold scope, and with self
+// set also the user the content or key belongs to, as long as their
+// credentials hold self.
+type grant struct {
+	roles  auth.Role
+	scope  auth.Scope
+	self   auth.Scope
+	denied string
+}
+
+// permissions is the permission matrix, checked by authorize.
+var permissions = [...]grant{
+	editContent: {
+		roles:  auth.RoleModerator | auth.RoleOwner | auth.RoleAdmin,
+		scope:  auth.ScopeModerate,
+		self:   auth.ScopePost,
+		denied: "Only the author, the forum owner, its moderators or an admin can edit this",
+	},
+	manageModerators: {
+		roles:  auth.RoleOwner | auth.RoleAdmin,
+		scope:  auth.ScopeModerate,
+		denied: "Only the forum owner or an admin can manage its moderators",
+	},
+	manageWebhooks: {
+		roles:  auth.RoleModerator | auth.RoleOwner | auth.RoleAdmin,
+		scope:  auth.ScopeModerate,
+		denied: "Only the forum owner, its moderators or an admin can manage its webhooks",
+	},
+	manageAdmins: {
+		roles:  auth.RoleAdmin,
+		scope:  auth.ScopeAdmin,
+		denied: "Only an admin can grant or revoke the admin role",
+	},
+	manageKeys: {
+		roles:  auth.RoleAdmin,
+		scope:  auth.ScopeAdmin,
+		self:   auth.ScopeAdmin,
+		denied: "Only the user itself or an admin can manage its API keys",
+	},
+	clearData: {
+		roles:  auth.RoleAdmin,
+		scope:  auth.ScopeAdmin,
+		denied: "Only an admin can clear the database",
+	},
+}
+
+// authorize checks that the request holds p for content written by or key
+// of owner in forum; either may be empty when the permission does not
+// depend on it. Like actAs it lets everything through with auth disabled.
+func (u *ForumUsecase) authorize(ctx context.Context, p permission, forum, owner string) error {
+	if !u.cfg.Auth.Enabled {
+		return nil
+	}
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return models.ErrorUnauthorized
+	}
+	if principal.Operator {
+		return nil
+	}
+	g := permissions[p]
+	// The scope is checked before anything else, so an API key cannot get
+	// past it through a route or field asking for less.
+	self := g.self != 0 && owner != "" && strings.EqualFold(principal.Nickname, owner)
+	scope := g.scope
+	if self {
+		scope = g.self
+	}
+	if !principal.Can(scope) {
+		return fmt.Errorf("%w: API key lacks the %s scope", models.ErrorForbidden, scope)
+	}
+	if self {
+		return nil
+	}
+	role, err := u.repo.GetRoles(ctx, principal.Nickname, forum)
+	if err != nil {
+		return err
+	}
+	if role&g.roles == 0 {
+		return fmt.Errorf("%w: %s", models.ErrorForbidden, g.denied)
+	}
+	return nil
+}
+
+func (u *ForumUsecase) GetModerators(ctx context.Context, slug string) ([]models.User, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetModerators(ctx, forum.Slug)
+}
+
+func (u *ForumUsecase) AddModerator(ctx context.Context, slug, nickname string) error {
+	forum, err := u.moderatedForum(ctx, slug, nickname)
+	if err != nil {
+		return err
+	}
+	return u.repo.AddModerator(ctx, forum.Slug, nickname)
+}
+
+func (u *ForumUsecase) RemoveModerator(ctx context.Context, slug, nickname string) error {
+	forum, err := u.moderatedForum(ctx, slug, nickname)
+	if err != nil {
+		return err
+	}
+	return u.repo.RemoveModerator(ctx, forum.Slug, nickname)
+}
+
+// moderatedForum returns the forum whose moderators the request changes.
+func (u *ForumUsecase) moderatedForum(ctx context.Context, slug, nickname string) (models.Forum, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	v.nickname("nickname", nickname)
+	if err := v.err(); err != nil {
+		return models.Forum{}, err
+	}
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	return forum, u.authorize(ctx, manageModerators, forum.Slug, "")
+}
+
+// SetAdmin grants or revokes the admin role.
+func (u *ForumUsecase) SetAdmin(ctx context.Context, nickname string, admin bool) error {
+	v := &validator{}
+	v.nickname("nickname", nickname)
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := u.authorize(ctx, manageAdmins, "", ""); err != nil {
+		return err
+	}
+	return u.repo.SetAdmin(ctx, nickname, admin)
+}
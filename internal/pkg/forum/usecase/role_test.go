@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"strings"
+	"testing"
+)
+
+const (
+	testForum  = "pirate-stories"
+	testAuthor = "j.sparrow"
+)
+
+// rolesRepo answers GetRoles the way the repository does: admin holds
+// everywhere, the forum roles only towards their forum.
+type rolesRepo struct {
+	forum.ForumRepository
+	admins     map[string]bool
+	forumRoles map[string]auth.Role
+	lookups    int
+	err        error
+}
+
+func (r *rolesRepo) GetRoles(ctx context.Context, nickname, forum string) (auth.Role, error) {
+	r.lookups++
+	if r.err != nil {
+		return 0, r.err
+	}
+	role := auth.RoleMember
+	if r.admins[strings.ToLower(nickname)] {
+		role |= auth.RoleAdmin
+	}
+	if strings.EqualFold(forum, testForum) {
+		role |= r.forumRoles[strings.ToLower(nickname)]
+	}
+	return role, nil
+}
+
+func newRolesRepo() *rolesRepo {
+	return &rolesRepo{
+		admins: map[string]bool{"e.swann": true},
+		forumRoles: map[string]auth.Role{
+			"h.barbossa": auth.RoleModerator,
+			"d.jones":    auth.RoleOwner,
+		},
+	}
+}
+
+// target is what each permission is checked against: the forum and the
+// author of the content or owner of the keys.
+var targets = [...]struct {
+	name         string
+	forum, owner string
+}{
+	editContent:      {"editContent", testForum, testAuthor},
+	manageModerators: {"manageModerators", testForum, ""},
+	manageWebhooks:   {"manageWebhooks", testForum, ""},
+	manageAdmins:     {"manageAdmins", "", ""},
+	manageKeys:       {"manageKeys", "", testAuthor},
+	clearData:        {"clearData", "", ""},
+}
+
+type outcome int
+
+const (
+	allowed outcome = iota
+	// deniedScope is a 403 for the credentials, before any role lookup.
+	deniedScope
+	// deniedRole is a 403 for the roles of the user.
+	deniedRole
+)
+
+func TestAuthorize(t *testing.T) {
+	const (
+		ok    = allowed
+		scope = deniedScope
+		role  = deniedRole
+	)
+	sessions := []struct {
+		name     string
+		nickname string
+		operator bool
+	}{
+		{"member", "w.turner", false},
+		{"author", testAuthor, false},
+		{"moderator", "h.barbossa", false},
+		{"owner", "d.jones", false},
+		{"admin", "e.swann", false},
+		{"operator", "", true},
+	}
+	// Outcomes in the order of the permissions: editContent,
+	// manageModerators, manageWebhooks, manageAdmins, manageKeys, clearData.
+	cases := []struct {
+		credentials string
+		scopes      auth.Scope
+		want        map[string][len(permissions)]outcome
+	}{
+		{"session", auth.AllScopes, map[string][len(permissions)]outcome{
+			"member":    {role, role, role, role, role, role},
+			"author":    {ok, role, role, role, ok, role},
+			"moderator": {ok, role, ok, role, role, role},
+			"owner":     {ok, ok, ok, role, role, role},
+			"admin":     {ok, ok, ok, ok, ok, ok},
+			"operator":  {ok, ok, ok, ok, ok, ok},
+		}},
+		{"read key", auth.ScopeRead, map[string][len(permissions)]outcome{
+			"member":    {scope, scope, scope, scope, scope, scope},
+			"author":    {scope, scope, scope, scope, scope, scope},
+			"moderator": {scope, scope, scope, scope, scope, scope},
+			"owner":     {scope, scope, scope, scope, scope, scope},
+			"admin":     {scope, scope, scope, scope, scope, scope},
+			"operator":  {ok, ok, ok, ok, ok, ok},
+		}},
+		{"read, post and vote key", auth.ScopeRead | auth.ScopePost | auth.ScopeVote, map[string][len(permissions)]outcome{
+			"member":    {scope, scope, scope, scope, scope, scope},
+			"author":    {ok, scope, scope, scope, scope, scope},
+			"moderator": {scope, scope, scope, scope, scope, scope},
+			"owner":     {scope, scope, scope, scope, scope, scope},
+			"admin":     {scope, scope, scope, scope, scope, scope},
+			"operator":  {ok, ok, ok, ok, ok, ok},
+		}},
+		{"moderate key", auth.ScopeRead | auth.ScopeModerate, map[string][len(permissions)]outcome{
+			"member":    {role, role, role, scope, scope, scope},
+			"author":    {scope, role, role, scope, scope, scope},
+			"moderator": {ok, role, ok, scope, scope, scope},
+			"owner":     {ok, ok, ok, scope, scope, scope},
+			"admin":     {ok, ok, ok, scope, scope, scope},
+			"operator":  {ok, ok, ok, ok, ok, ok},
+		}},
+		{"admin key", auth.ScopeAdmin, map[string][len(permissions)]outcome{
+			"member":    {scope, scope, scope, role, role, role},
+			"author":    {scope, scope, scope, role, ok, role},
+			"moderator": {scope, scope, scope, role, role, role},
+			"owner":     {scope, scope, scope, role, role, role},
+			"admin":     {scope, scope, scope, ok, ok, ok},
+			"operator":  {ok, ok, ok, ok, ok, ok},
+		}},
+	}
+
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	for _, tc := range cases {
+		for _, session := range sessions {
+			principal := auth.Principal{Nickname: session.nickname, Scopes: tc.scopes, Operator: session.operator}
+			if tc.scopes == auth.AllScopes {
+				principal.Session = "session"
+			} else {
+				principal.APIKey = "key"
+			}
+			for p, target := range targets {
+				name := tc.credentials + "/" + session.name + "/" + target.name
+				want := tc.want[session.name][p]
+				t.Run(name, func(t *testing.T) {
+					repo := newRolesRepo()
+					u := &ForumUsecase{repo: repo, cfg: cfg}
+					ctx := auth.NewContext(context.Background(), principal)
+					err := u.authorize(ctx, permission(p), target.forum, target.owner)
+
+					switch want {
+					case allowed:
+						if err != nil {
+							t.Fatalf("got %v, want allowed", err)
+						}
+					case deniedScope:
+						if !errors.Is(err, models.ErrorForbidden) || !strings.Contains(err.Error(), "API key lacks the") {
+							t.Fatalf("got %v, want forbidden for the scope", err)
+						}
+						if repo.lookups != 0 {
+							t.Error("roles were looked up for credentials without the scope")
+						}
+					case deniedRole:
+						if !errors.Is(err, models.ErrorForbidden) || !strings.Contains(err.Error(), permissions[p].denied) {
+							t.Fatalf("got %v, want forbidden with %q", err, permissions[p].denied)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestAuthorizeWithoutPrincipal(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	repo := newRolesRepo()
+	u := &ForumUsecase{repo: repo, cfg: cfg}
+	for p, target := range targets {
+		err := u.authorize(context.Background(), permission(p), target.forum, target.owner)
+		if !errors.Is(err, models.ErrorUnauthorized) {
+			t.Errorf("%s: got %v, want unauthorized", target.name, err)
+		}
+	}
+	if repo.lookups != 0 {
+		t.Error("roles were looked up without a principal")
+	}
+}
+
+func TestAuthorizeAuthDisabled(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = false
+	repo := newRolesRepo()
+	u := &ForumUsecase{repo: repo, cfg: cfg}
+	for p, target := range targets {
+		if err := u.authorize(context.Background(), permission(p), target.forum, target.owner); err != nil {
+			t.Errorf("%s: got %v, want allowed", target.name, err)
+		}
+	}
+}
+
+func TestAuthorizeSelfIgnoresCase(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	u := &ForumUsecase{repo: newRolesRepo(), cfg: cfg}
+	ctx := auth.NewContext(context.Background(), auth.Principal{Nickname: "J.Sparrow", Scopes: auth.AllScopes})
+	if err := u.authorize(ctx, editContent, testForum, testAuthor); err != nil {
+		t.Errorf("got %v, want the author allowed", err)
+	}
+}
+
+func TestAuthorizeRoleLookupError(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.Enabled = true
+	repo := newRolesRepo()
+	repo.err = models.ErrorInternal
+	u := &ForumUsecase{repo: repo, cfg: cfg}
+	ctx := auth.NewContext(context.Background(), auth.Principal{Nickname: "d.jones", Scopes: auth.AllScopes})
+	if err := u.authorize(ctx, manageModerators, testForum, ""); !errors.Is(err, models.ErrorInternal) {
+		t.Errorf("got %v, want the lookup error", err)
+	}
+}
@@ -3,45 +3,127 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/cursor"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/events"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/pkg/forum"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/webhooks"
 	"strconv"
 )
 
 type ForumUsecase struct {
-	repo forum.ForumRepository
+	repo      forum.ForumRepository
+	cfg       *config.Config
+	signer    *cursor.Signer
+	tokens    *auth.Tokens
+	passwords *auth.Passwords
+	events    *events.Broker
+	hooks     *webhooks.Dispatcher
 }
 
-func NewForumUsecase(repo forum.ForumRepository) *ForumUsecase {
+// NewForumUsecase publishes thread changes to broker and queues webhook
+// deliveries for the subscriptions hooks knows of. Either may be nil when
+// the feature is off.
+func NewForumUsecase(repo forum.ForumRepository, cfg *config.Config, broker *events.Broker, hooks *webhooks.Dispatcher) *ForumUsecase {
 	return &ForumUsecase{
-		repo: repo,
+		repo:      repo,
+		cfg:       cfg,
+		signer:    cursor.NewSigner(cfg.Pagination.CursorSecret),
+		tokens:    auth.NewTokens(cfg.Auth.Secret, cfg.Auth.AccessTTL.Std()),
+		passwords: auth.NewPasswords(cfg.Auth.BcryptCost),
+		events:    broker,
+		hooks:     hooks,
 	}
 }
 
 func (u *ForumUsecase) GetUser(ctx context.Context, nickname string) (models.User, error) {
+	v := &validator{}
+	v.nickname("nickname", nickname)
+	if err := v.err(); err != nil {
+		return models.User{}, err
+	}
 	return u.repo.GetUser(ctx, nickname)
 }
 
 func (u *ForumUsecase) CreateUser(ctx context.Context, user models.User) ([]models.User, error) {
-	return u.repo.CreateUser(ctx, user)
+	if err := validateUser(user, true, u.cfg.Auth.Enabled); err != nil {
+		return nil, err
+	}
+	if user.Password != "" {
+		hash, err := u.passwords.Hash(user.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.Password = hash
+	}
+	result, err := u.repo.CreateUser(ctx, user)
+	if err == nil {
+		metrics.Created(metrics.EntityUser, 1)
+	}
+	return result, err
 }
 
 func (u *ForumUsecase) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
+	if err := validateUser(user, false, false); err != nil {
+		return models.User{}, err
+	}
+	if err := u.actAs(ctx, user.Nickname); err != nil {
+		return models.User{}, err
+	}
 	return u.repo.UpdateUser(ctx, user)
 }
 
 func (u *ForumUsecase) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
-	return u.repo.CreateForum(ctx, forum)
+	if err := validateForum(forum); err != nil {
+		return models.Forum{}, err
+	}
+	if err := u.actAs(ctx, forum.User); err != nil {
+		return models.Forum{}, err
+	}
+	result, err := u.repo.CreateForum(ctx, forum)
+	if err == nil {
+		metrics.Created(metrics.EntityForum, 1)
+	}
+	return result, err
 }
 func (u *ForumUsecase) GetForum(ctx context.Context, slug string) (models.Forum, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	if err := v.err(); err != nil {
+		return models.Forum{}, err
+	}
 	return u.repo.GetForum(ctx, slug)
 }
 
 func (u *ForumUsecase) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
-	return u.repo.CreateThread(ctx, thread)
+	if err := validateThread(thread); err != nil {
+		return models.Thread{}, err
+	}
+	if err := u.actAs(ctx, thread.Author); err != nil {
+		return models.Thread{}, err
+	}
+	result, err := u.repo.CreateThread(ctx, thread)
+	if err == nil {
+		metrics.Created(metrics.EntityThread, 1)
+		u.notify(ctx, result.Forum, models.WebhookThreadCreated, result)
+	}
+	return result, err
 }
 
 func (u *ForumUsecase) GetThreads(ctx context.Context, slug, limit, since, desc string) ([]models.Thread, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.sinceTime(since)
+	v.desc(desc)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
 	_, err := u.repo.GetForum(ctx, slug)
 	if errors.Is(err, models.ErrorNotFound) {
 		return nil, err
@@ -51,6 +133,15 @@ func (u *ForumUsecase) GetThreads(ctx context.Context, slug, limit, since, desc
 }
 
 func (u *ForumUsecase) GetUsers(ctx context.Context, slug, limit, since, desc string) ([]models.User, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.sinceNickname(since)
+	v.desc(desc)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
 	_, err := u.repo.GetForum(ctx, slug)
 	if errors.Is(err, models.ErrorNotFound) {
 		return nil, err
@@ -60,6 +151,9 @@ func (u *ForumUsecase) GetUsers(ctx context.Context, slug, limit, since, desc st
 }
 
 func (u *ForumUsecase) CheckThreadByIdOrSlug(ctx context.Context, slugOrId string) (models.Thread, error) {
+	if err := validateSlugOrId(slugOrId); err != nil {
+		return models.Thread{}, err
+	}
 	intValue, err := strconv.Atoi(slugOrId)
 	if err != nil {
 		return u.repo.GetThreadBySlug(ctx, slugOrId)
@@ -68,32 +162,182 @@ func (u *ForumUsecase) CheckThreadByIdOrSlug(ctx context.Context, slugOrId strin
 	}
 }
 func (u *ForumUsecase) CreatePosts(ctx context.Context, posts models.PostsList, thread models.Thread) (models.PostsList, error) {
-	return u.repo.CreatePosts(ctx, posts, thread)
+	if err := validatePosts(posts); err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		if err := u.actAs(ctx, post.Author); err != nil {
+			return nil, err
+		}
+	}
+	result, err := u.repo.CreatePosts(ctx, posts, thread)
+	if err == nil {
+		metrics.Created(metrics.EntityPost, len(result))
+		for _, post := range result {
+			u.events.Publish(thread.ID, events.PostCreated, post)
+		}
+		u.notify(ctx, thread.Forum, models.WebhookPostsCreated, result)
+	}
+	return result, err
 }
 
 func (u *ForumUsecase) Vote(ctx context.Context, vote models.Vote) error {
-	return u.repo.Vote(ctx, vote)
+	if err := validateVote(vote); err != nil {
+		return err
+	}
+	if err := u.actAs(ctx, vote.Nickname); err != nil {
+		return err
+	}
+	err := u.repo.Vote(ctx, vote)
+	if err == nil {
+		metrics.Created(metrics.EntityVote, 1)
+		// The vote count is only worth a query when someone listens.
+		if u.events.Watched(vote.Thread) || u.hooks.Wanted(models.WebhookVote) {
+			if thread, err := u.repo.GetThreadById(ctx, vote.Thread); err == nil {
+				u.events.Publish(thread.ID, events.VoteChanged, thread)
+				u.notify(ctx, thread.Forum, models.WebhookVote, thread)
+			}
+		}
+	}
+	return err
 }
 
 func (u *ForumUsecase) GetPost(ctx context.Context, id string, related []string) (models.PostFull, error) {
-	idInt, _ := strconv.Atoi(id)
+	idInt, err := validatePostID(id)
+	if err != nil {
+		return models.PostFull{}, err
+	}
+	if err = validateRelated(related); err != nil {
+		return models.PostFull{}, err
+	}
 	return u.repo.GetPost(ctx, idInt, related)
 }
 
 func (u *ForumUsecase) GetThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int) ([]models.Post, error) {
+	v := &validator{}
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.sinceID(since)
+	v.desc(desc)
+	v.sort(sort)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
 	return u.repo.GetThreadPosts(ctx, limit, since, desc, sort, threadId)
 }
+
+// StreamThreadPosts is GetThreadPosts for callers that can consume the posts
+// as they are read; the validation errors are returned before fn is called.
+// count, when not nil, is given the number of posts before the first one.
+func (u *ForumUsecase) StreamThreadPosts(ctx context.Context, limit, since, desc, sort string, threadId int, count func(total int), fn func(models.Post) error) error {
+	v := &validator{}
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	v.sinceID(since)
+	v.desc(desc)
+	v.sort(sort)
+	if err := v.err(); err != nil {
+		return err
+	}
+	return u.repo.StreamThreadPosts(ctx, limit, since, desc, sort, threadId, count, fn)
+}
 func (u *ForumUsecase) UpdateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
-	return u.repo.UpdateThread(ctx, thread)
+	if thread.Slug != "" {
+		if err := validateSlugOrId(thread.Slug); err != nil {
+			return models.Thread{}, err
+		}
+	}
+	if u.cfg.Auth.Enabled {
+		var current models.Thread
+		var err error
+		if thread.Slug != "" {
+			current, err = u.repo.GetThreadBySlug(ctx, thread.Slug)
+		} else {
+			current, err = u.repo.GetThreadById(ctx, thread.ID)
+		}
+		if err != nil {
+			return models.Thread{}, err
+		}
+		if err = u.authorize(ctx, editContent, current.Forum, current.Author); err != nil {
+			return models.Thread{}, err
+		}
+	}
+	result, err := u.repo.UpdateThread(ctx, thread)
+	if err == nil {
+		u.events.Publish(result.ID, events.ThreadUpdated, result)
+	}
+	return result, err
 }
 
 func (u *ForumUsecase) UpdatePost(ctx context.Context, post models.PostUpdate) (models.Post, error) {
-	return u.repo.UpdatePost(ctx, post)
+	if _, err := validatePostID(strconv.Itoa(post.ID)); err != nil {
+		return models.Post{}, err
+	}
+	if u.cfg.Auth.Enabled {
+		current, err := u.repo.GetPost(ctx, post.ID, nil)
+		if err != nil {
+			return models.Post{}, err
+		}
+		if err = u.authorize(ctx, editContent, current.Post.Forum, current.Post.Author); err != nil {
+			return models.Post{}, err
+		}
+	}
+	result, err := u.repo.UpdatePost(ctx, post)
+	if err == nil {
+		u.events.Publish(result.Thread, events.PostEdited, result)
+		u.notify(ctx, result.Forum, models.WebhookPostEdited, result)
+	}
+	return result, err
 }
+
+// SubscribeThread streams the changes of a thread, resuming after
+// lastEventID when it is set.
+func (u *ForumUsecase) SubscribeThread(threadId int, lastEventID string) (*events.Subscription, error) {
+	v := &validator{}
+	id := v.eventID(lastEventID)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if u.events == nil {
+		return nil, models.ErrorNotFound
+	}
+	sub, err := u.events.Subscribe(threadId, id)
+	if errors.Is(err, events.ErrClosed) {
+		// Draining: the client should reconnect to another instance.
+		return nil, fmt.Errorf("%w: Server is shutting down, reconnect later", models.ErrorUnavailable)
+	}
+	return sub, err
+}
+
+// GetUsersByNicknames, GetForumsBySlugs and GetThreadsByIds serve batched
+// lookups. Keys that do not exist are left out of the result.
+func (u *ForumUsecase) GetUsersByNicknames(ctx context.Context, nicknames []string) ([]models.User, error) {
+	if len(nicknames) == 0 {
+		return []models.User{}, nil
+	}
+	return u.repo.GetUsersByNicknames(ctx, nicknames)
+}
+
+func (u *ForumUsecase) GetForumsBySlugs(ctx context.Context, slugs []string) ([]models.Forum, error) {
+	if len(slugs) == 0 {
+		return []models.Forum{}, nil
+	}
+	return u.repo.GetForumsBySlugs(ctx, slugs)
+}
+
+func (u *ForumUsecase) GetThreadsByIds(ctx context.Context, ids []int) ([]models.Thread, error) {
+	if len(ids) == 0 {
+		return []models.Thread{}, nil
+	}
+	return u.repo.GetThreadsByIds(ctx, ids)
+}
+
 func (u *ForumUsecase) GetStatus() models.Status {
 	return u.repo.GetStatus()
 }
 
-func (u *ForumUsecase) Clear() {
+func (u *ForumUsecase) Clear(ctx context.Context) error {
+	if err := u.authorize(ctx, clearData, "", ""); err != nil {
+		return err
+	}
 	u.repo.Clear()
+	return nil
 }
@@ -0,0 +1,350 @@
+package usecase
+
+import (
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/auth"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/cursor"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxNicknameLength = 64
+	maxSlugLength     = 128
+	maxEmailLength    = 254
+	maxURLLength      = 2048
+	minSecretLength   = 16
+	maxSecretLength   = 256
+	minPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes.
+	maxPasswordLength = 72
+	maxKeyNameLength  = 128
+)
+
+var (
+	nicknamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	// A slug may not consist of digits only, otherwise it could not be told
+	// apart from a thread id in /thread/{slug_or_id}.
+	slugPattern = regexp.MustCompile(`^[A-Za-z0-9_-]*[A-Za-z_-][A-Za-z0-9_-]*$`)
+)
+
+var webhookEvents = []string{
+	models.WebhookThreadCreated,
+	models.WebhookPostsCreated,
+	models.WebhookPostEdited,
+	models.WebhookVote,
+}
+
+type validator struct {
+	fields []models.FieldError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, models.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &models.ValidationError{Fields: v.fields}
+}
+
+func (v *validator) required(field, value string) bool {
+	if value == "" {
+		v.fail(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *validator) nickname(field, value string) {
+	switch {
+	case len(value) > maxNicknameLength:
+		v.fail(field, "must be at most %d characters", maxNicknameLength)
+	case !nicknamePattern.MatchString(value):
+		v.fail(field, "may contain only latin letters, digits, '_' and '.'")
+	}
+}
+
+func (v *validator) password(field, value string) {
+	if len(value) < minPasswordLength || len(value) > maxPasswordLength {
+		v.fail(field, "must be %d to %d bytes", minPasswordLength, maxPasswordLength)
+	}
+}
+
+func (v *validator) slug(field, value string) {
+	switch {
+	case len(value) > maxSlugLength:
+		v.fail(field, "must be at most %d characters", maxSlugLength)
+	case !slugPattern.MatchString(value):
+		v.fail(field, "may contain only latin letters, digits, '_' and '-' and must not be a number")
+	}
+}
+
+func (v *validator) email(field, value string) {
+	if len(value) > maxEmailLength {
+		v.fail(field, "must be at most %d characters", maxEmailLength)
+		return
+	}
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		v.fail(field, "is not a valid email address")
+	}
+}
+
+func (v *validator) limit(value string, max int) {
+	if value == "" {
+		return
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > max {
+		v.fail("limit", "must be an integer between 1 and %d", max)
+	}
+}
+
+func (v *validator) desc(value string) {
+	if value != "" && value != "true" && value != "false" {
+		v.fail("desc", "must be true or false")
+	}
+}
+
+func (v *validator) sinceTime(value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+		v.fail("since", "must be an RFC 3339 timestamp")
+	}
+}
+
+func (v *validator) sinceID(value string) {
+	if value == "" {
+		return
+	}
+	if id, err := strconv.Atoi(value); err != nil || id < 0 {
+		v.fail("since", "must be a post id")
+	}
+}
+
+// eventID parses the Last-Event-ID of a resuming event stream; 0 means the
+// stream starts fresh.
+func (v *validator) eventID(value string) uint64 {
+	if value == "" {
+		return 0
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		v.fail("last_event_id", "must be the id of an event of this stream")
+	}
+	return id
+}
+
+// id parses a positive row id given in the path or query; 0 means it was
+// invalid.
+func (v *validator) id(field, value string) int {
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		v.fail(field, "must be a positive id")
+		return 0
+	}
+	return id
+}
+
+func (v *validator) webhookURL(value string) {
+	parsed, err := url.Parse(value)
+	switch {
+	case len(value) > maxURLLength:
+		v.fail("url", "must be at most %d characters", maxURLLength)
+	case err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "":
+		v.fail("url", "must be an absolute http or https URL")
+	}
+}
+
+func (v *validator) webhookEvents(events []string) {
+	if len(events) == 0 {
+		v.fail("events", "must name at least one of %s", strings.Join(webhookEvents, ", "))
+	}
+	for _, event := range events {
+		known := false
+		for _, webhookEvent := range webhookEvents {
+			known = known || event == webhookEvent
+		}
+		if !known {
+			v.fail("events", "%q is not one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+	}
+}
+
+func (v *validator) webhookSecret(value string) {
+	if len(value) < minSecretLength || len(value) > maxSecretLength {
+		v.fail("secret", "must be %d to %d characters", minSecretLength, maxSecretLength)
+	}
+}
+
+// apiKey checks the fields of an API key to issue and returns its scopes.
+func (v *validator) apiKey(key models.APIKey) auth.Scope {
+	if v.required("name", key.Name) && len(key.Name) > maxKeyNameLength {
+		v.fail("name", "must be at most %d characters", maxKeyNameLength)
+	}
+	if len(key.Scopes) == 0 {
+		v.fail("scopes", "must name at least one of %s", strings.Join(auth.ScopeNames, ", "))
+	}
+	var scopes auth.Scope
+	for _, name := range key.Scopes {
+		scope, ok := auth.ParseScope(name)
+		if !ok {
+			v.fail("scopes", "%q is not one of %s", name, strings.Join(auth.ScopeNames, ", "))
+		}
+		scopes |= scope
+	}
+	return scopes
+}
+
+func (v *validator) deliveryStatus(value string) {
+	switch value {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		v.fail("status", "must be one of %s, %s, %s", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead)
+	}
+}
+
+func (v *validator) sinceNickname(value string) {
+	if value != "" {
+		v.nickname("since", value)
+	}
+}
+
+// cursor decodes a v2 page cursor, which must have been issued for the same
+// listing: same parent, sort and direction.
+func (v *validator) cursor(signer *cursor.Signer, token, scope string) *cursor.Cursor {
+	if token == "" {
+		return nil
+	}
+	c, err := signer.Decode(token, scope)
+	if err != nil {
+		v.fail("cursor", "must be a cursor returned by this listing with the same sort and desc")
+		return nil
+	}
+	return &c
+}
+
+func (v *validator) sort(value string) {
+	switch value {
+	case "", "flat", "tree", "parent_tree":
+	default:
+		v.fail("sort", "must be one of flat, tree, parent_tree")
+	}
+}
+
+// validateUser checks a user to create, or when create is false a profile
+// update. requirePassword makes a password mandatory on create.
+func validateUser(user models.User, create, requirePassword bool) error {
+	v := &validator{}
+	v.nickname("nickname", user.Nickname)
+	if create {
+		v.required("fullname", user.Fullname)
+		if v.required("email", user.Email) {
+			v.email("email", user.Email)
+		}
+		if user.Password != "" {
+			v.password("password", user.Password)
+		} else if requirePassword {
+			v.required("password", user.Password)
+		}
+	} else if user.Email != "" {
+		v.email("email", user.Email)
+	}
+	return v.err()
+}
+
+func validateForum(forum models.Forum) error {
+	v := &validator{}
+	v.required("title", forum.Title)
+	if v.required("user", forum.User) {
+		v.nickname("user", forum.User)
+	}
+	if v.required("slug", forum.Slug) {
+		v.slug("slug", forum.Slug)
+	}
+	return v.err()
+}
+
+func validateThread(thread models.Thread) error {
+	v := &validator{}
+	v.required("title", thread.Title)
+	v.required("message", thread.Message)
+	if v.required("author", thread.Author) {
+		v.nickname("author", thread.Author)
+	}
+	v.slug("forum", thread.Forum)
+	if thread.Slug != "" {
+		v.slug("slug", thread.Slug)
+	}
+	return v.err()
+}
+
+func validatePosts(posts models.PostsList) error {
+	v := &validator{}
+	for i, post := range posts {
+		prefix := fmt.Sprintf("posts[%d].", i)
+		if v.required(prefix+"author", post.Author) {
+			v.nickname(prefix+"author", post.Author)
+		}
+		v.required(prefix+"message", post.Message)
+		if post.Parent < 0 {
+			v.fail(prefix+"parent", "must be a post id")
+		}
+	}
+	return v.err()
+}
+
+func validateVote(vote models.Vote) error {
+	v := &validator{}
+	if v.required("nickname", vote.Nickname) {
+		v.nickname("nickname", vote.Nickname)
+	}
+	if vote.Voice != -1 && vote.Voice != 1 {
+		v.fail("voice", "must be -1 or 1")
+	}
+	return v.err()
+}
+
+func validateSlugOrId(slugOrId string) error {
+	v := &validator{}
+	if id, err := strconv.Atoi(slugOrId); err == nil {
+		if id < 1 {
+			v.fail("slug_or_id", "must be a positive thread id or a slug")
+		}
+	} else {
+		v.slug("slug_or_id", slugOrId)
+	}
+	return v.err()
+}
+
+func validatePostID(id string) (int, error) {
+	idInt, err := strconv.Atoi(id)
+	if err != nil || idInt < 1 {
+		v := &validator{}
+		v.fail("id", "must be a positive post id")
+		return 0, v.err()
+	}
+	return idInt, nil
+}
+
+func validateRelated(related []string) error {
+	v := &validator{}
+	for _, item := range related {
+		switch item {
+		case "user", "forum", "thread":
+		default:
+			v.fail("related", "unknown relation %q, expected user, forum or thread", item)
+		}
+	}
+	return v.err()
+}
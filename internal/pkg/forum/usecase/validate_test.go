@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// invalidFields returns the fields a validation error names, or nil when
+// err is nil.
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *models.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("got %v, want a *models.ValidationError", err)
+	}
+	if !errors.Is(err, models.ErrorBadRequest) {
+		t.Errorf("%v does not match models.ErrorBadRequest", err)
+	}
+	fields := make([]string, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		if field.Message == "" {
+			t.Errorf("field %s has no message", field.Field)
+		}
+		fields = append(fields, field.Field)
+	}
+	return fields
+}
+
+func checkFields(t *testing.T, err error, want []string) {
+	t.Helper()
+	if got := invalidFields(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("invalid fields = %v, want %v (error: %v)", got, want, err)
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	valid := models.User{Nickname: "j.sparrow", Fullname: "Jack Sparrow", Email: "captain@blackpearl.sea", Password: "rum-is-gone"}
+	with := func(change func(*models.User)) models.User {
+		user := valid
+		change(&user)
+		return user
+	}
+	tests := []struct {
+		name            string
+		user            models.User
+		create          bool
+		requirePassword bool
+		want            []string
+	}{
+		{"valid create", valid, true, true, nil},
+		{"valid create without password", with(func(u *models.User) { u.Password = "" }), true, false, nil},
+		{"nickname with dash", with(func(u *models.User) { u.Nickname = "jack-sparrow" }), true, false, []string{"nickname"}},
+		{"nickname with space", with(func(u *models.User) { u.Nickname = "jack sparrow" }), true, false, []string{"nickname"}},
+		{"nickname not latin", with(func(u *models.User) { u.Nickname = "джек" }), true, false, []string{"nickname"}},
+		{"nickname empty", with(func(u *models.User) { u.Nickname = "" }), true, false, []string{"nickname"}},
+		{"nickname at max length", with(func(u *models.User) { u.Nickname = strings.Repeat("a", maxNicknameLength) }), true, false, nil},
+		{"nickname too long", with(func(u *models.User) { u.Nickname = strings.Repeat("a", maxNicknameLength+1) }), true, false, []string{"nickname"}},
+		{"fullname missing", with(func(u *models.User) { u.Fullname = "" }), true, false, []string{"fullname"}},
+		{"email missing", with(func(u *models.User) { u.Email = "" }), true, false, []string{"email"}},
+		{"email without at", with(func(u *models.User) { u.Email = "captain.blackpearl.sea" }), true, false, []string{"email"}},
+		{"email with name", with(func(u *models.User) { u.Email = "Jack <captain@blackpearl.sea>" }), true, false, []string{"email"}},
+		{"email too long", with(func(u *models.User) { u.Email = strings.Repeat("a", maxEmailLength) + "@sea" }), true, false, []string{"email"}},
+		{"password missing", with(func(u *models.User) { u.Password = "" }), true, true, []string{"password"}},
+		{"password too short", with(func(u *models.User) { u.Password = "short" }), true, false, []string{"password"}},
+		{"password too long", with(func(u *models.User) { u.Password = strings.Repeat("p", maxPasswordLength+1) }), true, false, []string{"password"}},
+		{"all missing", models.User{Nickname: "jack"}, true, true, []string{"fullname", "email", "password"}},
+		{"update with nothing set", models.User{Nickname: "jack"}, false, true, nil},
+		{"update with bad email", models.User{Nickname: "jack", Email: "nope"}, false, false, []string{"email"}},
+		{"update with bad nickname", models.User{Nickname: "ja ck"}, false, false, []string{"nickname"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateUser(tt.user, tt.create, tt.requirePassword), tt.want)
+		})
+	}
+}
+
+func TestValidateForum(t *testing.T) {
+	tests := []struct {
+		name  string
+		forum models.Forum
+		want  []string
+	}{
+		{"valid", models.Forum{Title: "Pirates", User: "j.sparrow", Slug: "pirate-stories"}, nil},
+		{"slug with underscore and digits", models.Forum{Title: "Pirates", User: "jack", Slug: "pirates_17"}, nil},
+		{"missing everything", models.Forum{}, []string{"title", "user", "slug"}},
+		{"bad user", models.Forum{Title: "Pirates", User: "jack!", Slug: "pirates"}, []string{"user"}},
+		{"slug all digits", models.Forum{Title: "Pirates", User: "jack", Slug: "1717"}, []string{"slug"}},
+		{"slug with dot", models.Forum{Title: "Pirates", User: "jack", Slug: "pirates.sea"}, []string{"slug"}},
+		{"slug with space", models.Forum{Title: "Pirates", User: "jack", Slug: "pirate stories"}, []string{"slug"}},
+		{"slug at max length", models.Forum{Title: "Pirates", User: "jack", Slug: strings.Repeat("s", maxSlugLength)}, nil},
+		{"slug too long", models.Forum{Title: "Pirates", User: "jack", Slug: strings.Repeat("s", maxSlugLength+1)}, []string{"slug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateForum(tt.forum), tt.want)
+		})
+	}
+}
+
+func TestValidateThread(t *testing.T) {
+	valid := models.Thread{Title: "Treasure", Message: "Where is it?", Author: "jack", Forum: "pirates"}
+	with := func(change func(*models.Thread)) models.Thread {
+		thread := valid
+		change(&thread)
+		return thread
+	}
+	tests := []struct {
+		name   string
+		thread models.Thread
+		want   []string
+	}{
+		{"valid", valid, nil},
+		{"valid with slug", with(func(th *models.Thread) { th.Slug = "treasure-1" }), nil},
+		{"missing title and message", with(func(th *models.Thread) { th.Title, th.Message = "", "" }), []string{"title", "message"}},
+		{"missing author", with(func(th *models.Thread) { th.Author = "" }), []string{"author"}},
+		{"bad author", with(func(th *models.Thread) { th.Author = "jack sparrow" }), []string{"author"}},
+		{"bad forum", with(func(th *models.Thread) { th.Forum = "pirates!" }), []string{"forum"}},
+		{"slug all digits", with(func(th *models.Thread) { th.Slug = "42" }), []string{"slug"}},
+		{"slug too long", with(func(th *models.Thread) { th.Slug = strings.Repeat("t", maxSlugLength+1) }), []string{"slug"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateThread(tt.thread), tt.want)
+		})
+	}
+}
+
+func TestValidatePosts(t *testing.T) {
+	tests := []struct {
+		name  string
+		posts models.PostsList
+		want  []string
+	}{
+		{"empty list", models.PostsList{}, nil},
+		{"valid", models.PostsList{{Author: "jack", Message: "Ahoy"}, {Author: "will", Message: "Hi", Parent: 1}}, nil},
+		{"missing author and message", models.PostsList{{}}, []string{"posts[0].author", "posts[0].message"}},
+		{"bad author in second post", models.PostsList{{Author: "jack", Message: "Ahoy"}, {Author: "wi ll", Message: "Hi"}}, []string{"posts[1].author"}},
+		{"negative parent", models.PostsList{{Author: "jack", Message: "Ahoy", Parent: -1}}, []string{"posts[0].parent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validatePosts(tt.posts), tt.want)
+		})
+	}
+}
+
+func TestValidateVote(t *testing.T) {
+	tests := []struct {
+		name string
+		vote models.Vote
+		want []string
+	}{
+		{"upvote", models.Vote{Nickname: "jack", Voice: 1}, nil},
+		{"downvote", models.Vote{Nickname: "jack", Voice: -1}, nil},
+		{"zero voice", models.Vote{Nickname: "jack", Voice: 0}, []string{"voice"}},
+		{"voice of two", models.Vote{Nickname: "jack", Voice: 2}, []string{"voice"}},
+		{"voice of minus two", models.Vote{Nickname: "jack", Voice: -2}, []string{"voice"}},
+		{"missing nickname", models.Vote{Voice: 1}, []string{"nickname"}},
+		{"bad nickname", models.Vote{Nickname: "ja/ck", Voice: 1}, []string{"nickname"}},
+		{"everything wrong", models.Vote{Voice: 5}, []string{"nickname", "voice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateVote(tt.vote), tt.want)
+		})
+	}
+}
+
+func TestValidateSlugOrId(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"42", nil},
+		{"treasure-1", nil},
+		{"0", []string{"slug_or_id"}},
+		{"-3", []string{"slug_or_id"}},
+		{"", []string{"slug_or_id"}},
+		{"bad slug", []string{"slug_or_id"}},
+		{strings.Repeat("s", maxSlugLength+1), []string{"slug_or_id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			checkFields(t, validateSlugOrId(tt.value), tt.want)
+		})
+	}
+}
+
+func TestValidatePostID(t *testing.T) {
+	tests := []struct {
+		value  string
+		wantID int
+		want   []string
+	}{
+		{"1", 1, nil},
+		{"9000", 9000, nil},
+		{"0", 0, []string{"id"}},
+		{"-1", 0, []string{"id"}},
+		{"abc", 0, []string{"id"}},
+		{"", 0, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			id, err := validatePostID(tt.value)
+			checkFields(t, err, tt.want)
+			if id != tt.wantID {
+				t.Errorf("id = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestValidateRelated(t *testing.T) {
+	tests := []struct {
+		name    string
+		related []string
+		want    []string
+	}{
+		{"none", nil, nil},
+		{"all", []string{"user", "forum", "thread"}, nil},
+		{"unknown", []string{"author"}, []string{"related"}},
+		{"one unknown of two", []string{"user", "posts"}, []string{"related"}},
+		{"two unknown", []string{"post", "votes"}, []string{"related", "related"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkFields(t, validateRelated(tt.related), tt.want)
+		})
+	}
+}
+
+// TestListingParameters covers the query parameters shared by the list
+// endpoints.
+func TestListingParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *validator)
+		want  []string
+	}{
+		{"limit absent", func(v *validator) { v.limit("", 100) }, nil},
+		{"limit 1", func(v *validator) { v.limit("1", 100) }, nil},
+		{"limit at max", func(v *validator) { v.limit("100", 100) }, nil},
+		{"limit 0", func(v *validator) { v.limit("0", 100) }, []string{"limit"}},
+		{"limit above max", func(v *validator) { v.limit("101", 100) }, []string{"limit"}},
+		{"limit not a number", func(v *validator) { v.limit("ten", 100) }, []string{"limit"}},
+		{"desc absent", func(v *validator) { v.desc("") }, nil},
+		{"desc true", func(v *validator) { v.desc("true") }, nil},
+		{"desc false", func(v *validator) { v.desc("false") }, nil},
+		{"desc 1", func(v *validator) { v.desc("1") }, []string{"desc"}},
+		{"desc TRUE", func(v *validator) { v.desc("TRUE") }, []string{"desc"}},
+		{"since time absent", func(v *validator) { v.sinceTime("") }, nil},
+		{"since time RFC 3339", func(v *validator) { v.sinceTime("2017-01-01T00:00:00.000Z") }, nil},
+		{"since time with offset", func(v *validator) { v.sinceTime("2017-01-01T03:00:00+03:00") }, nil},
+		{"since time date only", func(v *validator) { v.sinceTime("2017-01-01") }, []string{"since"}},
+		{"since id absent", func(v *validator) { v.sinceID("") }, nil},
+		{"since id", func(v *validator) { v.sinceID("17") }, nil},
+		{"since id zero", func(v *validator) { v.sinceID("0") }, nil},
+		{"since id negative", func(v *validator) { v.sinceID("-1") }, []string{"since"}},
+		{"since id not a number", func(v *validator) { v.sinceID("first") }, []string{"since"}},
+		{"since nickname", func(v *validator) { v.sinceNickname("jack") }, nil},
+		{"since nickname invalid", func(v *validator) { v.sinceNickname("ja ck") }, []string{"since"}},
+		{"sort absent", func(v *validator) { v.sort("") }, nil},
+		{"sort flat", func(v *validator) { v.sort("flat") }, nil},
+		{"sort tree", func(v *validator) { v.sort("tree") }, nil},
+		{"sort parent_tree", func(v *validator) { v.sort("parent_tree") }, nil},
+		{"sort unknown", func(v *validator) { v.sort("random") }, []string{"sort"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &validator{}
+			tt.check(v)
+			checkFields(t, v.err(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Webhooks belong to a forum and are addressed by forum slug and id. The
+// deliveries are queued by notify right after the write they report, not
+// in its transaction, so an event is lost if the process dies in between.
+// Changing webhooks and reading or replaying their deliveries is left to
+// the forum owner, its moderators and admins.
+
+func (u *ForumUsecase) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	v := &validator{}
+	v.slug("slug", webhook.Forum)
+	v.webhookURL(webhook.URL)
+	v.webhookEvents(webhook.Events)
+	if webhook.Secret != "" {
+		v.webhookSecret(webhook.Secret)
+	}
+	if err := v.err(); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := u.authorize(ctx, manageWebhooks, webhook.Forum, ""); err != nil {
+		return models.Webhook{}, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = newSecret()
+	}
+	webhook.Events = unique(webhook.Events)
+
+	result, err := u.repo.CreateWebhook(ctx, webhook)
+	if err == nil {
+		u.refreshWebhooks(ctx)
+	}
+	return result, err
+}
+
+func (u *ForumUsecase) GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if _, err := u.repo.GetForum(ctx, slug); err != nil {
+		return nil, err
+	}
+	return u.repo.GetWebhooks(ctx, slug)
+}
+
+func (u *ForumUsecase) GetWebhook(ctx context.Context, slug, id string) (models.Webhook, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	idInt := v.id("id", id)
+	if err := v.err(); err != nil {
+		return models.Webhook{}, err
+	}
+	return u.repo.GetWebhook(ctx, slug, idInt)
+}
+
+func (u *ForumUsecase) UpdateWebhook(ctx context.Context, update models.WebhookUpdate) (models.Webhook, error) {
+	v := &validator{}
+	v.slug("slug", update.Forum)
+	v.id("id", strconv.Itoa(update.ID))
+	if update.URL != "" {
+		v.webhookURL(update.URL)
+	}
+	if update.Events != nil {
+		v.webhookEvents(update.Events)
+	}
+	if update.Secret != "" {
+		v.webhookSecret(update.Secret)
+	}
+	if err := v.err(); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := u.authorize(ctx, manageWebhooks, update.Forum, ""); err != nil {
+		return models.Webhook{}, err
+	}
+	if update.Events != nil {
+		update.Events = unique(update.Events)
+	}
+
+	result, err := u.repo.UpdateWebhook(ctx, update)
+	if err == nil {
+		u.refreshWebhooks(ctx)
+	}
+	return result, err
+}
+
+// DeleteWebhook removes a webhook together with its queued deliveries and
+// delivery log.
+func (u *ForumUsecase) DeleteWebhook(ctx context.Context, slug, id string) error {
+	v := &validator{}
+	v.slug("slug", slug)
+	idInt := v.id("id", id)
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := u.authorize(ctx, manageWebhooks, slug, ""); err != nil {
+		return err
+	}
+	err := u.repo.DeleteWebhook(ctx, slug, idInt)
+	if err == nil {
+		u.refreshWebhooks(ctx)
+	}
+	return err
+}
+
+// GetWebhookDeliveries is the delivery log of a webhook in id order,
+// optionally only the deliveries with the given status.
+func (u *ForumUsecase) GetWebhookDeliveries(ctx context.Context, slug, id, limit, since, status, desc string) ([]models.WebhookDelivery, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	idInt := v.id("id", id)
+	v.limit(limit, u.cfg.Pagination.MaxLimit)
+	var sinceID int
+	if since != "" {
+		sinceID = v.id("since", since)
+	}
+	v.deliveryStatus(status)
+	v.desc(desc)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if err := u.authorize(ctx, manageWebhooks, slug, ""); err != nil {
+		return nil, err
+	}
+
+	webhook, err := u.repo.GetWebhook(ctx, slug, idInt)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetWebhookDeliveries(ctx, webhook.ID, status, int64(sinceID), desc == "true", u.pageSize(limit))
+}
+
+// RetryWebhookDelivery queues a delivery again with a fresh set of
+// attempts; it is how a dead-lettered delivery is replayed.
+func (u *ForumUsecase) RetryWebhookDelivery(ctx context.Context, slug, id, deliveryId string) (models.WebhookDelivery, error) {
+	v := &validator{}
+	v.slug("slug", slug)
+	idInt := v.id("id", id)
+	deliveryInt := v.id("delivery", deliveryId)
+	if err := v.err(); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if err := u.authorize(ctx, manageWebhooks, slug, ""); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	webhook, err := u.repo.GetWebhook(ctx, slug, idInt)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return u.repo.RetryWebhookDelivery(ctx, webhook.ID, int64(deliveryInt))
+}
+
+// notify queues a delivery of event to the webhooks of forum listening to
+// it. The write it reports has succeeded, so a failure is logged instead of
+// failing the request.
+func (u *ForumUsecase) notify(ctx context.Context, forum, event string, data interface{}) {
+	if !u.hooks.Subscribed(forum, event) {
+		return
+	}
+	payload, err := json.Marshal(models.WebhookPayload{Event: event, Forum: forum, Occurred: time.Now(), Data: data})
+	if err == nil {
+		err = u.repo.EnqueueWebhookDeliveries(ctx, forum, event, payload)
+	}
+	if err != nil {
+		log.Printf("queue %s webhooks of forum %q: %v", event, forum, err)
+	}
+}
+
+// refreshWebhooks makes this instance see a webhook change at once rather
+// than at the next poll of the dispatcher.
+func (u *ForumUsecase) refreshWebhooks(ctx context.Context) {
+	if err := u.hooks.Refresh(ctx); err != nil {
+		log.Printf("reload webhook subscriptions: %v", err)
+	}
+}
+
+func newSecret() string {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+func unique(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/migrate"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"time"
+)
+
+type Checker struct {
+	conn     *pgxpool.Pool
+	migrator *migrate.Migrator
+	ready    func() bool
+	cfg      config.Health
+	started  time.Time
+}
+
+// NewChecker builds a checker; ready reports whether the server is still
+// accepting traffic and flips to false once shutdown starts.
+func NewChecker(conn *pgxpool.Pool, migrator *migrate.Migrator, ready func() bool, cfg *config.Config) *Checker {
+	return &Checker{
+		conn:     conn,
+		migrator: migrator,
+		ready:    ready,
+		cfg:      cfg.Health,
+		started:  time.Now(),
+	}
+}
+
+// Live only proves the process is scheduling goroutines; it deliberately
+// ignores the database so a database outage does not restart every pod.
+func (c *Checker) Live(ctx context.Context) models.Health {
+	return report(
+		run("process", func() error { return nil }, func() string {
+			return fmt.Sprintf("up %s", time.Since(c.started).Round(time.Second))
+		}),
+	)
+}
+
+func (c *Checker) Ready(ctx context.Context) models.Health {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.CheckTimeout.Std())
+	defer cancel()
+
+	return report(
+		run("server", c.checkServer, nil),
+		run("database", func() error { return c.conn.Ping(ctx) }, nil),
+		c.checkPool(),
+		c.checkMigrations(ctx),
+	)
+}
+
+func (c *Checker) checkServer() error {
+	if !c.ready() {
+		return fmt.Errorf("shutting down")
+	}
+	return nil
+}
+
+func (c *Checker) checkPool() models.HealthCheck {
+	stat := c.conn.Stat()
+	saturation := float64(stat.AcquiredConns()) / float64(stat.MaxConns())
+	message := fmt.Sprintf("%d of %d connections acquired", stat.AcquiredConns(), stat.MaxConns())
+	return run("pool", func() error {
+		if saturation >= c.cfg.MaxPoolSaturation {
+			return fmt.Errorf("%s, saturation %.2f exceeds %.2f", message, saturation, c.cfg.MaxPoolSaturation)
+		}
+		return nil
+	}, func() string { return message })
+}
+
+func (c *Checker) checkMigrations(ctx context.Context) models.HealthCheck {
+	var version int
+	return run("migrations", func() error {
+		var err error
+		version, err = c.migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version != c.migrator.Latest() {
+			return fmt.Errorf("schema version %d, binary expects %d", version, c.migrator.Latest())
+		}
+		return nil
+	}, func() string { return fmt.Sprintf("schema version %d", version) })
+}
+
+func run(name string, check func() error, describe func() string) models.HealthCheck {
+	start := time.Now()
+	err := check()
+	result := models.HealthCheck{
+		Name:      name,
+		Status:    models.HealthOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = models.HealthFail
+		result.Message = err.Error()
+	} else if describe != nil {
+		result.Message = describe()
+	}
+	return result
+}
+
+func report(checks ...models.HealthCheck) models.Health {
+	result := models.Health{Status: models.HealthOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != models.HealthOK {
+			result.Status = models.HealthFail
+		}
+	}
+	return result
+}
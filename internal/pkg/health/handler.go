@@ -0,0 +1,34 @@
+package health
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/utils"
+	"net/http"
+)
+
+type Handler struct {
+	checker *Checker
+}
+
+func NewHealthHandler(checker *Checker) *Handler {
+	return &Handler{
+		checker: checker,
+	}
+}
+
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	respond(w, r, h.checker.Live(r.Context()))
+}
+
+func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	respond(w, r, h.checker.Ready(r.Context()))
+}
+
+func respond(w http.ResponseWriter, r *http.Request, health models.Health) {
+	w.Header().Set("Cache-Control", "no-store")
+	if health.Status != models.HealthOK {
+		utils.Response(w, r, http.StatusServiceUnavailable, health)
+		return
+	}
+	utils.Response(w, r, http.StatusOK, health)
+}
@@ -0,0 +1,167 @@
+// Package ratelimit keeps in-memory token buckets for the rate limiting
+// middleware.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Policy is a token bucket refilled at Rate tokens per second and holding
+// at most Burst tokens.
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Decision describes the most constrained bucket a request was checked
+// against.
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is the wait until a rejected request would be allowed.
+	RetryAfter time.Duration
+	// Reset is the wait until the bucket is full again.
+	Reset time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter holds one bucket per (route, key) for the routes that have a
+// policy. Buckets are created full, so a bucket that has refilled completely
+// carries no information and Evict drops it; memory is bounded by the
+// clients active within one refill period.
+type Limiter struct {
+	policies map[string]Policy
+	// maxRefill is the longest any policy takes to refill an empty bucket.
+	maxRefill time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewLimiter(policies map[string]Policy) *Limiter {
+	l := &Limiter{policies: policies, buckets: make(map[string]*bucket), now: time.Now}
+	for _, policy := range policies {
+		if refill := seconds(float64(policy.Burst) / policy.Rate); refill > l.maxRefill {
+			l.maxRefill = refill
+		}
+	}
+	return l
+}
+
+// Limited reports whether route has a policy.
+func (l *Limiter) Limited(route string) bool {
+	_, ok := l.policies[route]
+	return ok
+}
+
+// Allow takes one token from the bucket of every key under route, or from
+// none of them if any is empty, so a request rejected for its nickname does
+// not also use up its IP's allowance. Routes without a policy are always
+// allowed.
+func (l *Limiter) Allow(route string, keys ...string) Decision {
+	policy, ok := l.policies[route]
+	if !ok {
+		return Decision{Allowed: true}
+	}
+	now := l.now()
+	burst := float64(policy.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	buckets := make([]*bucket, 0, len(keys))
+	allowed := true
+	for _, key := range keys {
+		b, ok := l.buckets[route+"\x00"+key]
+		if !ok {
+			b = &bucket{tokens: burst, updated: now}
+			l.buckets[route+"\x00"+key] = b
+		}
+		b.refill(now, policy.Rate, burst)
+		if b.tokens < 1 {
+			allowed = false
+		}
+		buckets = append(buckets, b)
+	}
+
+	decision := Decision{Allowed: allowed, Limit: policy.Burst, Remaining: policy.Burst}
+	for _, b := range buckets {
+		if allowed {
+			b.tokens--
+		}
+		if remaining := int(math.Floor(b.tokens)); remaining < decision.Remaining {
+			decision.Remaining = remaining
+		}
+		if reset := seconds((burst - b.tokens) / policy.Rate); reset > decision.Reset {
+			decision.Reset = reset
+		}
+		if b.tokens < 1 {
+			if retry := seconds((1 - b.tokens) / policy.Rate); retry > decision.RetryAfter {
+				decision.RetryAfter = retry
+			}
+		}
+	}
+	if decision.Allowed {
+		decision.RetryAfter = 0
+	}
+	return decision
+}
+
+// Evict drops the buckets that have certainly refilled completely, that is
+// the ones untouched for longer than the slowest policy takes to refill.
+func (l *Limiter) Evict() int {
+	deadline := l.now().Add(-l.maxRefill)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	evicted := 0
+	for key, b := range l.buckets {
+		if b.updated.Before(deadline) {
+			delete(l.buckets, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Run evicts refilled buckets every interval until ctx is done.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.Evict()
+		}
+	}
+}
+
+// Len is the number of buckets currently held.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+func (b *bucket) refill(now time.Time, rate, burst float64) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*rate)
+	}
+	b.updated = now
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
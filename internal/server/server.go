@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"google.golang.org/grpc"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Server owns the HTTP listener, the optional gRPC one and the database
+// pool and tears them down in order: readiness off, in-flight requests and
+// calls drained, pool closed.
+type Server struct {
+	http     *http.Server
+	grpc     *grpc.Server
+	grpcAddr string
+	pool     *pgxpool.Pool
+	cfg      config.HTTP
+	ready    atomic.Bool
+}
+
+func New(cfg *config.Config, handler http.Handler, pool *pgxpool.Pool) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.HTTP.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.HTTP.ReadTimeout.Std(),
+			ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout.Std(),
+			WriteTimeout:      cfg.HTTP.WriteTimeout.Std(),
+			IdleTimeout:       cfg.HTTP.IdleTimeout.Std(),
+		},
+		pool: pool,
+		cfg:  cfg.HTTP,
+	}
+}
+
+// WithGRPC makes Run serve grpcServer on addr next to the HTTP server.
+func (s *Server) WithGRPC(grpcServer *grpc.Server, addr string) *Server {
+	s.grpc = grpcServer
+	s.grpcAddr = addr
+	return s
+}
+
+// OnShutdown registers f to run when shutdown starts draining, to end
+// long-lived responses that would otherwise hold the drain until its
+// timeout.
+func (s *Server) OnShutdown(f func()) *Server {
+	s.http.RegisterOnShutdown(f)
+	return s
+}
+
+// Ready reports whether the server accepts new traffic. It turns false as
+// soon as shutdown begins so load balancers stop routing to the instance.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Run serves until ctx is cancelled and then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	var grpcListener net.Listener
+	if s.grpc != nil {
+		if grpcListener, err = net.Listen("tcp", s.grpcAddr); err != nil {
+			_ = listener.Close()
+			return err
+		}
+	}
+	return s.Serve(ctx, listener, grpcListener)
+}
+
+// Serve is Run on listeners opened by the caller; grpcListener is ignored
+// unless WithGRPC was called.
+func (s *Server) Serve(ctx context.Context, listener, grpcListener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.http.Serve(listener)
+	}()
+	grpcErr := make(chan error, 1)
+	if s.grpc != nil {
+		go func() {
+			grpcErr <- s.grpc.Serve(grpcListener)
+		}()
+		log.Printf("grpc listening on %s", grpcListener.Addr())
+	}
+	s.ready.Store(true)
+	log.Printf("listening on %s", listener.Addr())
+
+	select {
+	case err := <-serveErr:
+		s.ready.Store(false)
+		if s.grpc != nil {
+			s.grpc.Stop()
+		}
+		s.pool.Close()
+		return err
+	case err := <-grpcErr:
+		s.ready.Store(false)
+		_ = s.http.Close()
+		s.pool.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.ready.Store(false)
+	log.Printf("shutting down, waiting %s before draining", s.cfg.ShutdownDelay)
+	time.Sleep(s.cfg.ShutdownDelay.Std())
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.cfg.DrainTimeout.Std())
+	defer cancel()
+	grpcDrained := make(chan struct{})
+	if s.grpc != nil {
+		go func() {
+			s.grpc.GracefulStop()
+			close(grpcDrained)
+		}()
+	}
+	err := s.http.Shutdown(drainCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("drain timeout of %s exceeded, closing remaining connections", s.cfg.DrainTimeout)
+		err = s.http.Close()
+	}
+	if s.grpc != nil {
+		select {
+		case <-grpcDrained:
+		case <-drainCtx.Done():
+			log.Printf("drain timeout of %s exceeded, cancelling remaining gRPC calls", s.cfg.DrainTimeout)
+			s.grpc.Stop()
+			<-grpcDrained
+		}
+	}
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	if s.grpc != nil {
+		if serveErr := <-grpcErr; serveErr != nil && err == nil {
+			err = serveErr
+		}
+	}
+
+	s.pool.Close()
+	log.Print("shutdown complete")
+	return err
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// lazyPool is a pool that never connects, for the server to close.
+func lazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	poolConfig, err := pgxpool.ParseConfig("postgres://forum@127.0.0.1:1/forum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConfig.LazyConnect = true
+	pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, within time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(within)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s did not happen within %s", what, within)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShutdownDrainsSlowRequest(t *testing.T) {
+	const slow = time.Second
+	cfg := config.Default()
+	cfg.HTTP.ShutdownDelay = config.Duration(200 * time.Millisecond)
+	cfg.HTTP.DrainTimeout = config.Duration(3 * time.Second)
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(slow)
+		_, _ = io.WriteString(w, "done")
+	})
+	srv := New(cfg, handler, lazyPool(t))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, listener, nil)
+	}()
+	waitFor(t, time.Second, "readiness", srv.Ready)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+		at     time.Time
+	}
+	response := make(chan result, 1)
+	go func() {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://" + addr + "/slow")
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{status: resp.StatusCode, body: string(body), err: err, at: time.Now()}
+	}()
+	<-started
+
+	signalled := time.Now()
+	if err = syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, 100*time.Millisecond, "readiness turning off", func() bool { return !srv.Ready() })
+	select {
+	case <-response:
+		t.Fatal("in-flight request finished before readiness turned off")
+	default:
+	}
+
+	var refusedAt time.Time
+	waitFor(t, cfg.HTTP.ShutdownDelay.Std()+time.Second, "refusing new connections", func() bool {
+		conn, err := net.DialTimeout("tcp", addr, 50*time.Millisecond)
+		if err != nil {
+			refusedAt = time.Now()
+			return true
+		}
+		_ = conn.Close()
+		return false
+	})
+
+	got := <-response
+	if got.err != nil {
+		t.Fatalf("in-flight request failed: %v", got.err)
+	}
+	if got.status != http.StatusOK || got.body != "done" {
+		t.Fatalf("in-flight request got %d %q, want 200 \"done\"", got.status, got.body)
+	}
+	if !refusedAt.Before(got.at) {
+		t.Errorf("new connections were still accepted after the in-flight request completed")
+	}
+
+	select {
+	case err = <-served:
+		if err != nil {
+			t.Fatalf("Serve returned %v", err)
+		}
+	case <-time.After(cfg.HTTP.ShutdownDelay.Std() + cfg.HTTP.DrainTimeout.Std()):
+		t.Fatal("Serve did not return within the drain timeout")
+	}
+	if elapsed := time.Since(signalled); elapsed > cfg.HTTP.ShutdownDelay.Std()+cfg.HTTP.DrainTimeout.Std() {
+		t.Errorf("shutdown took %s", elapsed)
+	}
+	if srv.Ready() {
+		t.Error("server is ready after shutdown")
+	}
+	if _, err = net.DialTimeout("tcp", addr, 50*time.Millisecond); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("dial after shutdown: %v, want connection refused", err)
+	}
+}
@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/mailru/easyjson"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeMsgPack  = "application/msgpack"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var errUnsupportedType = errors.New("type has no representation in this encoding")
+
+// Codec is one wire representation of the models. JSON is the default and
+// can encode anything; the others cover the public models only.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSON     Codec = jsonCodec{}
+	MsgPack  Codec = msgpackCodec{}
+	Protobuf Codec = protobufCodec{}
+)
+
+var codecsByMediaType = map[string]Codec{
+	ContentTypeJSON:                   JSON,
+	ContentTypeMsgPack:                MsgPack,
+	"application/x-msgpack":           MsgPack,
+	"application/vnd.msgpack":         MsgPack,
+	ContentTypeProtobuf:               Protobuf,
+	"application/protobuf":            Protobuf,
+	"application/vnd.google.protobuf": Protobuf,
+}
+
+// Negotiate picks the response codec from the Accept header, preferring the
+// highest q-value and falling back to JSON.
+func Negotiate(r *http.Request) Codec {
+	if r == nil {
+		return JSON
+	}
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return JSON
+	}
+
+	type candidate struct {
+		codec Codec
+		q     float64
+		order int
+	}
+	var candidates []candidate
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			candidates = append(candidates, candidate{JSON, q, i})
+		} else if codec, ok := codecsByMediaType[mediaType]; ok {
+			candidates = append(candidates, candidate{codec, q, i})
+		}
+	}
+	if len(candidates) == 0 {
+		return JSON
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].codec
+}
+
+// RequestCodec picks the codec for the request body from Content-Type.
+func RequestCodec(r *http.Request) (Codec, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return JSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, models.ErrorUnsupportedMediaType
+	}
+	codec, ok := codecsByMediaType[mediaType]
+	if !ok {
+		return nil, models.ErrorUnsupportedMediaType
+	}
+	return codec, nil
+}
+
+// Decode reads the request body into v using the codec named by the
+// request Content-Type.
+func Decode(r *http.Request, v interface{}) error {
+	codec, err := RequestCodec(r)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return BadRequest(err)
+	}
+	if err = codec.Unmarshal(data, v); err != nil {
+		return BadRequest(err)
+	}
+	return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if unmarshaler, ok := v.(easyjson.Unmarshaler); ok {
+		return easyjson.Unmarshal(data, unmarshaler)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// msgpackCodec reuses the json struct tags so both encodings share field
+// names.
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMsgPack
+}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := msgpack.NewDecoder(bytes.NewReader(data))
+	decoder.SetCustomStructTag("json")
+	return decoder.Decode(v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	var message proto.Message
+	switch value := v.(type) {
+	case models.User:
+		message = pb.FromUser(value)
+	case []models.User:
+		message = pb.FromUsers(value)
+	case models.Forum:
+		message = pb.FromForum(value)
+	case models.Thread:
+		message = pb.FromThread(value)
+	case []models.Thread:
+		message = pb.FromThreads(value)
+	case models.Post:
+		message = pb.FromPost(value)
+	case []models.Post:
+		message = pb.FromPosts(value)
+	case models.PostsList:
+		message = pb.FromPosts(value)
+	case models.PostFull:
+		message = pb.FromPostFull(value)
+	case models.PostUpdate:
+		message = pb.FromPostUpdate(value)
+	case models.Vote:
+		message = pb.FromVote(value)
+	case models.Status:
+		message = pb.FromStatus(value)
+	case models.Error:
+		message = pb.FromError(value)
+	case models.ThreadsPage:
+		message = pb.FromThreadsPage(value)
+	case models.PostsPage:
+		message = pb.FromPostsPage(value)
+	case models.UsersPage:
+		message = pb.FromUsersPage(value)
+	default:
+		return nil, errUnsupportedType
+	}
+	return proto.Marshal(message)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	switch value := v.(type) {
+	case *models.User:
+		message := &pb.User{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *[]models.User:
+		message := &pb.UserList{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.Forum:
+		message := &pb.Forum{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.Thread:
+		message := &pb.Thread{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *[]models.Thread:
+		message := &pb.ThreadList{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.Post:
+		message := &pb.Post{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.PostsList:
+		message := &pb.PostList{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.PostFull:
+		message := &pb.PostFull{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.PostUpdate:
+		message := &pb.PostUpdate{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		// The id comes from the URL, keep it unless the body sets one.
+		update := message.Model()
+		if update.ID == 0 {
+			update.ID = value.ID
+		}
+		*value = update
+	case *models.Vote:
+		message := &pb.Vote{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.Status:
+		message := &pb.Status{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	case *models.Error:
+		message := &pb.Error{}
+		if err := proto.Unmarshal(data, message); err != nil {
+			return err
+		}
+		*value = message.Model()
+	default:
+		return errUnsupportedType
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// sameValue is reflect.DeepEqual that compares times as instants, since
+// MessagePack decodes them in the local zone.
+func sameValue(got, want interface{}) bool {
+	return reflect.DeepEqual(inUTC(reflect.ValueOf(got)).Interface(), inUTC(reflect.ValueOf(want)).Interface())
+}
+
+// inUTC returns a copy of v with every time moved to UTC.
+func inUTC(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return reflect.ValueOf(v.Interface().(time.Time).UTC())
+		}
+		result := reflect.New(v.Type()).Elem()
+		result.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if result.Field(i).CanSet() {
+				result.Field(i).Set(inUTC(v.Field(i)))
+			}
+		}
+		return result
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		result := reflect.New(v.Type().Elem())
+		result.Elem().Set(inUTC(v.Elem()))
+		return result
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(inUTC(v.Index(i)))
+		}
+		return result
+	}
+	return v
+}
+
+// roundTrip encodes value with codec, decodes it into a new value of the
+// same type and checks nothing was lost on the way.
+func roundTrip(t *testing.T, codec Codec, value interface{}) {
+	t.Helper()
+	data, err := codec.Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	decoded := reflect.New(reflect.TypeOf(value))
+	if err = codec.Unmarshal(data, decoded.Interface()); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got := decoded.Elem().Interface(); !sameValue(got, value) {
+		t.Errorf("round trip changed the value\n got: %+v\nwant: %+v", got, value)
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	created := time.Date(2023, time.March, 14, 15, 9, 26, 535000000, time.UTC)
+	user := models.User{Nickname: "j.sparrow", Fullname: "Jack Sparrow", About: "Captain", Email: "captain@blackpearl.sea", Version: 3}
+	forum := models.Forum{Title: "Pirate stories", User: "j.sparrow", Slug: "pirate-stories", Posts: 200000, Threads: 12}
+	thread := models.Thread{
+		ID:      42,
+		Title:   "Davy Jones cache",
+		Author:  "j.sparrow",
+		Forum:   "pirate-stories",
+		Message: "An urgent need to find the chest",
+		Votes:   -7,
+		Slug:    "jones",
+		Created: created,
+		Version: 2,
+	}
+	post := models.Post{
+		ID:       1337,
+		Parent:   1336,
+		Author:   "j.sparrow",
+		Message:  "We shall prevail. Or not.",
+		IsEdited: true,
+		Forum:    "pirate-stories",
+		Thread:   42,
+		Created:  created,
+		Version:  5,
+	}
+
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{"User", user},
+		{"Forum", forum},
+		{"Thread", thread},
+		{"Thread without slug or votes", models.Thread{ID: 1, Title: "t", Author: "a", Forum: "f", Message: "m", Created: created}},
+		{"Post", post},
+		{"Post at the root", models.Post{ID: 1, Author: "a", Message: "m", Forum: "f", Thread: 1, Created: created}},
+		{"PostFull", models.PostFull{Post: post, Author: &user, Thread: &thread, Forum: &forum}},
+		{"PostFull without related", models.PostFull{Post: post}},
+		{"PostFull without author", models.PostFull{Post: post, Thread: &thread, Forum: &forum}},
+		{"PostFull without thread", models.PostFull{Post: post, Author: &user, Forum: &forum}},
+		{"PostFull without forum", models.PostFull{Post: post, Author: &user, Thread: &thread}},
+		{"Vote up", models.Vote{Nickname: "j.sparrow", Voice: 1}},
+		{"Vote down", models.Vote{Nickname: "j.sparrow", Voice: -1}},
+		{"Status", models.Status{UsersCount: 1000, ForumsCount: 100, ThreadsCount: 10000, PostsCount: 1500000}},
+		{"empty Status", models.Status{}},
+	}
+
+	for _, codec := range []Codec{JSON, MsgPack, Protobuf} {
+		for _, tc := range cases {
+			t.Run(codec.ContentType()+"/"+tc.name, func(t *testing.T) {
+				roundTrip(t, codec, tc.value)
+			})
+		}
+	}
+}
+
+func TestCodecRoundTripLists(t *testing.T) {
+	created := time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC)
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{"users", []models.User{{Nickname: "a", Fullname: "A", Email: "a@example.com"}, {Nickname: "b", Fullname: "B", Email: "b@example.com", About: "b"}}},
+		{"threads", []models.Thread{{ID: 1, Title: "t", Author: "a", Forum: "f", Message: "m", Created: created}, {ID: 2, Title: "u", Author: "b", Forum: "f", Message: "n", Slug: "u", Votes: 3, Created: created}}},
+		{"posts", models.PostsList{{ID: 1, Author: "a", Message: "m", Forum: "f", Thread: 1, Created: created}, {ID: 2, Parent: 1, Author: "b", Message: "n", Forum: "f", Thread: 1, Created: created}}},
+	}
+
+	for _, codec := range []Codec{JSON, MsgPack, Protobuf} {
+		for _, tc := range cases {
+			t.Run(codec.ContentType()+"/"+tc.name, func(t *testing.T) {
+				roundTrip(t, codec, tc.value)
+			})
+		}
+	}
+}
+
+func TestProtobufRejectsUnsupportedTypes(t *testing.T) {
+	if _, err := Protobuf.Marshal(map[string]string{"a": "b"}); err != errUnsupportedType {
+		t.Errorf("Marshal: got %v, want %v", err, errUnsupportedType)
+	}
+	var target map[string]string
+	if err := Protobuf.Unmarshal(nil, &target); err != errUnsupportedType {
+		t.Errorf("Unmarshal: got %v, want %v", err, errUnsupportedType)
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"net/http"
+	"strings"
+)
+
+const (
+	ResourceUser     = "user"
+	ResourceForum    = "forum"
+	ResourceThread   = "thread"
+	ResourcePost     = "post"
+	ResourceWebhook  = "webhook"
+	ResourceDelivery = "delivery"
+	ResourceAPIKey   = "api key"
+
+	ResourceIdempotencyKey = "idempotency key"
+)
+
+// NewError is the single place where usecase errors are turned into an HTTP
+// status and a typed body. id identifies the missing or conflicting
+// resource and may be empty.
+func NewError(err error, resource, id string) (int, models.Error) {
+	body := models.Error{Resource: resource}
+	subject := resource
+	if id != "" {
+		subject = fmt.Sprintf("%s %q", resource, id)
+	}
+
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		body.Code = models.CodeBadRequest
+		body.Message = "Invalid request"
+		body.Details = validationErr.Fields
+		return http.StatusBadRequest, body
+	case errors.Is(err, models.ErrorUnsupportedMediaType):
+		body.Code = models.CodeUnsupportedMediaType
+		body.Message = "Content-Type must be one of " + ContentTypeJSON + ", " + ContentTypeMsgPack + ", " + ContentTypeProtobuf
+		return http.StatusUnsupportedMediaType, body
+	case errors.Is(err, models.ErrorBadRequest):
+		body.Code = models.CodeBadRequest
+		body.Message = strings.TrimPrefix(err.Error(), models.ErrorBadRequest.Error()+": ")
+		return http.StatusBadRequest, body
+	case errors.Is(err, models.ErrorUnauthorized):
+		body.Code = models.CodeUnauthorized
+		body.Message = "Authentication required"
+		if err != models.ErrorUnauthorized {
+			body.Message = strings.TrimPrefix(err.Error(), models.ErrorUnauthorized.Error()+": ")
+		}
+		return http.StatusUnauthorized, body
+	case errors.Is(err, models.ErrorForbidden):
+		body.Code = models.CodeForbidden
+		body.Message = fmt.Sprintf("Not allowed to modify %s", subject)
+		if err != models.ErrorForbidden {
+			body.Message = strings.TrimPrefix(err.Error(), models.ErrorForbidden.Error()+": ")
+		}
+		return http.StatusForbidden, body
+	case errors.Is(err, models.ErrorNotFound):
+		body.Code = models.CodeNotFound
+		body.Message = fmt.Sprintf("Can't find %s", subject)
+		return http.StatusNotFound, body
+	case errors.Is(err, models.ErrorPreconditionFailed):
+		body.Code = models.CodePreconditionFailed
+		body.Message = fmt.Sprintf("%s has changed since the version given in If-Match", subject)
+		return http.StatusPreconditionFailed, body
+	case errors.Is(err, models.ErrorUnprocessableEntity):
+		body.Code = models.CodeUnprocessableEntity
+		body.Message = fmt.Sprintf("%s was used with a different request", subject)
+		return http.StatusUnprocessableEntity, body
+	case errors.Is(err, models.ErrorPayloadTooLarge):
+		body.Code = models.CodePayloadTooLarge
+		body.Message = "Request body is too large"
+		if err != models.ErrorPayloadTooLarge {
+			body.Message = strings.TrimPrefix(err.Error(), models.ErrorPayloadTooLarge.Error()+": ")
+		}
+		return http.StatusRequestEntityTooLarge, body
+	case errors.Is(err, models.ErrorUnavailable):
+		body.Code = models.CodeUnavailable
+		body.Message = "Service unavailable, retry later"
+		if err != models.ErrorUnavailable {
+			body.Message = strings.TrimPrefix(err.Error(), models.ErrorUnavailable.Error()+": ")
+		}
+		return http.StatusServiceUnavailable, body
+	case errors.Is(err, models.ErrorTooManyRequests):
+		body.Code = models.CodeRateLimited
+		body.Message = "Too many requests, retry later"
+		return http.StatusTooManyRequests, body
+	case errors.Is(err, models.ErrorConflict):
+		body.Code = models.CodeConflict
+		body.Message = fmt.Sprintf("%s conflicts with existing data", subject)
+		return http.StatusConflict, body
+	}
+	body.Code = models.CodeInternal
+	body.Message = "Internal server error"
+	return http.StatusInternalServerError, body
+}
+
+func ErrorResponse(w http.ResponseWriter, r *http.Request, err error, resource, id string) {
+	status, body := NewError(err, resource, id)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="forum"`)
+	}
+	Response(w, r, status, body)
+}
+
+// BadRequest wraps a decoding failure so it maps to 400.
+func BadRequest(err error) error {
+	return fmt.Errorf("%w: Malformed request body: %v", models.ErrorBadRequest, err)
+}
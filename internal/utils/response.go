@@ -1,30 +1,141 @@
 package utils
 
 import (
-	"encoding/json"
-	"fmt"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func Response(w http.ResponseWriter, status int, body interface{}) {
-	if body != nil {
-		w.Header().Set("Content-Type", "application/json")
+// Response encodes body in the representation negotiated from the request's
+// Accept header. Types without a non-JSON representation fall back to JSON.
+func Response(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
 	}
+
+	codec, data, err := encode(r, body)
+	if err != nil {
+		log.Printf("encode %T as %s: %v", body, codec.ContentType(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.Header().Add("Vary", "Accept")
 	w.WriteHeader(status)
-	if status == http.StatusNotFound && body != nil {
-		jsn, err := json.Marshal(models.Error{Message: fmt.Sprintf("Can't find user with id #%s\\n", body)})
-		if err != nil {
-			return
-		}
-		_, _ = w.Write(jsn)
+	_, _ = w.Write(data)
+}
+
+// ConditionalResponse sends body with 200, an ETag and Last-Modified unless
+// lastModified is zero. An empty etag is replaced by a strong one computed
+// from the encoded representation. A request whose If-None-Match or
+// If-Modified-Since shows the client already has this representation gets
+// 304 without a body.
+func ConditionalResponse(w http.ResponseWriter, r *http.Request, body interface{}, etag string, lastModified time.Time) {
+	codec, data, err := encode(r, body)
+	if err != nil {
+		log.Printf("encode %T as %s: %v", body, codec.ContentType(), err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if body != nil {
-		jsn, err := json.Marshal(body)
-		if err != nil {
-			return
+
+	if etag == "" {
+		sum := sha256.Sum256(data)
+		etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	}
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Accept")
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", codec.ContentType())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// notModified evaluates the preconditions of RFC 9110 section 13.2.2: when
+// If-None-Match is present If-Modified-Since is ignored.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches uses the weak comparison If-None-Match calls for, so an ETag
+// weakened by the compression middleware still matches.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
 		}
-		_, _ = w.Write(jsn)
 	}
+	return false
+}
+
+// VersionETag is the entity tag of a versioned resource; the version names
+// the state of the row, so every encoding of that state shares it. It is
+// empty for an unknown version.
+func VersionETag(version int) string {
+	if version <= 0 {
+		return ""
+	}
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatchVersion returns the version an update must find, taken from an
+// If-Match header holding a VersionETag. It is 0 when the update is
+// unconditional: no header or "*", which the existing resource always
+// satisfies. Anything else cannot match a version and fails the precondition.
+// A weak tag is accepted too because compressed responses weaken the ETag.
+func IfMatchVersion(r *http.Request) (int, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+	tag := strings.TrimPrefix(ifMatch, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, models.ErrorPreconditionFailed
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, models.ErrorPreconditionFailed
+	}
+	return version, nil
+}
+
+func encode(r *http.Request, body interface{}) (Codec, []byte, error) {
+	codec := Negotiate(r)
+	data, err := codec.Marshal(body)
+	if errors.Is(err, errUnsupportedType) {
+		codec = JSON
+		data, err = codec.Marshal(body)
+	}
+	return codec, data, err
 }
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/pb"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+)
+
+// streamFlushEvery is how many items are written between flushes, so the
+// client sees progress without every row turning into its own chunk.
+const streamFlushEvery = 256
+
+var errUncounted = errors.New("MessagePack list streamed without its length")
+
+// ArrayStream writes a list response item by item in the negotiated
+// encoding. Without a Content-Length the server sends it chunked. JSON goes
+// out as an array and Protobuf as the repeated items field of the list
+// message and MessagePack as an array, all byte for byte what Response
+// produces for the whole slice. A MessagePack array starts with its length,
+// which has to be passed to Counter before the first item.
+//
+// Nothing is written before the first item, so a failure before that can
+// still be answered with a proper error; see Started.
+type ArrayStream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	codec   Codec
+	started bool
+	count   int
+	total   int
+	counted bool
+}
+
+func NewArrayStream(w http.ResponseWriter, r *http.Request) *ArrayStream {
+	return &ArrayStream{w: w, r: r, codec: Negotiate(r)}
+}
+
+// Started reports whether the status line has been sent, after which an
+// error can no longer change the response.
+func (s *ArrayStream) Started() bool {
+	return s.started
+}
+
+// Counter returns the function the number of items has to be passed to
+// before the first Write, or nil when the encoding does not need it.
+func (s *ArrayStream) Counter() func(total int) {
+	if s.codec != MsgPack {
+		return nil
+	}
+	return func(total int) {
+		s.total, s.counted = total, true
+	}
+}
+
+// Write sends one item. An error means the client is gone or the item could
+// not be encoded; the caller should stop producing items.
+func (s *ArrayStream) Write(item interface{}) error {
+	data, err := s.encodeItem(item)
+	if err != nil {
+		return err
+	}
+	if !s.started {
+		switch s.codec {
+		case JSON:
+			data = append([]byte{'['}, data...)
+		case MsgPack:
+			if !s.counted {
+				return errUncounted
+			}
+			data = append(msgpackArrayHeader(s.total), data...)
+		}
+		s.start()
+	} else if s.codec == JSON {
+		data = append([]byte{','}, data...)
+	}
+	if _, err = s.w.Write(data); err != nil {
+		return err
+	}
+	s.count++
+	if s.count%streamFlushEvery == 0 {
+		_ = http.NewResponseController(s.w).Flush()
+	}
+	return s.r.Context().Err()
+}
+
+// Close finishes a list that was written completely.
+func (s *ArrayStream) Close() {
+	if !s.started {
+		s.start()
+		switch s.codec {
+		case JSON:
+			_, _ = s.w.Write([]byte("[]"))
+		case MsgPack:
+			_, _ = s.w.Write(msgpackArrayHeader(0))
+		}
+		return
+	}
+	if s.codec == JSON {
+		_, _ = s.w.Write([]byte{']'})
+	}
+}
+
+func (s *ArrayStream) start() {
+	s.started = true
+	s.w.Header().Set("Content-Type", s.codec.ContentType())
+	s.w.Header().Add("Vary", "Accept")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *ArrayStream) encodeItem(item interface{}) ([]byte, error) {
+	if s.codec != Protobuf {
+		return s.codec.Marshal(item)
+	}
+	var message proto.Message
+	switch value := item.(type) {
+	case models.Post:
+		message = pb.FromPost(value)
+	case models.Thread:
+		message = pb.FromThread(value)
+	case models.User:
+		message = pb.FromUser(value)
+	default:
+		return nil, errUnsupportedType
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	// The list messages keep their elements in field 1.
+	field := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendBytes(field, data), nil
+}
+
+// msgpackArrayHeader is how MessagePack starts an array of n items.
+func msgpackArrayHeader(n int) []byte {
+	var buf bytes.Buffer
+	_ = msgpack.NewEncoder(&buf).EncodeArrayLen(n)
+	return buf.Bytes()
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func streamedPosts(n int) []models.Post {
+	posts := make([]models.Post, n)
+	created := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range posts {
+		posts[i] = models.Post{ID: i + 1, Author: "j.sparrow", Forum: "pirate-stories", Thread: 42, Message: "Yo ho " + strconv.Itoa(i), Created: created}
+	}
+	return posts
+}
+
+func listRequest(contentType string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/thread/42/posts", nil)
+	r.Header.Set("Accept", contentType)
+	return r
+}
+
+func TestArrayStreamMatchesResponse(t *testing.T) {
+	for _, codec := range []Codec{JSON, MsgPack, Protobuf} {
+		for _, n := range []int{0, 1, 2*streamFlushEvery + 1} {
+			t.Run(codec.ContentType()+"/"+strconv.Itoa(n), func(t *testing.T) {
+				posts := streamedPosts(n)
+				want := httptest.NewRecorder()
+				Response(want, listRequest(codec.ContentType()), http.StatusOK, models.PostsList(posts))
+
+				got := httptest.NewRecorder()
+				stream := NewArrayStream(got, listRequest(codec.ContentType()))
+				if count := stream.Counter(); count != nil {
+					count(len(posts))
+				}
+				for _, post := range posts {
+					if err := stream.Write(post); err != nil {
+						t.Fatal(err)
+					}
+				}
+				stream.Close()
+
+				if got.Code != http.StatusOK || got.Header().Get("Content-Type") != want.Header().Get("Content-Type") {
+					t.Errorf("got %d %q, want 200 %q", got.Code, got.Header().Get("Content-Type"), want.Header().Get("Content-Type"))
+				}
+				if !bytes.Equal(got.Body.Bytes(), want.Body.Bytes()) {
+					t.Errorf("streamed body differs from the whole response: %d bytes, want %d", got.Body.Len(), want.Body.Len())
+				}
+			})
+		}
+	}
+}
+
+func TestArrayStreamSendsItemsAsWritten(t *testing.T) {
+	for _, codec := range []Codec{JSON, MsgPack, Protobuf} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			stream := NewArrayStream(w, listRequest(codec.ContentType()))
+			if count := stream.Counter(); count != nil {
+				count(2)
+			}
+			if stream.Started() || w.Body.Len() != 0 {
+				t.Fatal("the stream started before the first item")
+			}
+			if err := stream.Write(streamedPosts(1)[0]); err != nil {
+				t.Fatal(err)
+			}
+			if !stream.Started() || w.Body.Len() == 0 {
+				t.Error("the first item was not sent by Write")
+			}
+		})
+	}
+}
+
+func TestArrayStreamMsgPackNeedsCount(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := NewArrayStream(w, listRequest(ContentTypeMsgPack))
+	if err := stream.Write(streamedPosts(1)[0]); !errors.Is(err, errUncounted) {
+		t.Fatalf("got %v, want %v", err, errUncounted)
+	}
+	if stream.Started() {
+		t.Error("the stream started without the count")
+	}
+	if NewArrayStream(w, listRequest(ContentTypeJSON)).Counter() != nil {
+		t.Error("JSON asks for the count")
+	}
+}
@@ -0,0 +1,345 @@
+// Package webhooks sends the queued webhook deliveries.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/metrics"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Delivery request headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed by the webhook secret, prefixed with
+// "sha256="; receivers should also reject old timestamps to stop replays.
+// Deliveries are at least once, so receivers deduplicate by delivery id.
+const (
+	HeaderEvent     = "X-Forum-Event"
+	HeaderDelivery  = "X-Forum-Delivery"
+	HeaderTimestamp = "X-Forum-Timestamp"
+	HeaderSignature = "X-Forum-Signature"
+)
+
+const (
+	purgeInterval = time.Hour
+	// Only this much of a response is read, and less of it is logged.
+	maxResponseBody = 64 << 10
+	maxErrorLength  = 512
+	completeTimeout = 5 * time.Second
+)
+
+// Job is a claimed delivery with the target of its webhook.
+type Job struct {
+	models.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// Result is the outcome of one attempt. NextAttempt is only meaningful for
+// a delivery left pending.
+type Result struct {
+	Status      string
+	LastStatus  int
+	LastError   string
+	NextAttempt time.Time
+}
+
+// Store is the part of the repository the dispatcher works on.
+type Store interface {
+	GetWebhookSubscriptions(ctx context.Context) ([]models.Webhook, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	CompleteWebhookDelivery(ctx context.Context, id int64, result Result) error
+	PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Dispatcher polls the delivery queue and sends due deliveries with up to
+// cfg.Workers in flight. It also keeps the set of events each forum has
+// subscribers for, refreshed every poll, so writes to forums without
+// webhooks skip queueing altogether; a webhook created on another instance
+// starts receiving events within a poll interval.
+type Dispatcher struct {
+	store  Store
+	cfg    config.Webhooks
+	client *http.Client
+
+	busy atomic.Int32
+	idle chan struct{}
+	wg   sync.WaitGroup
+
+	mu            sync.RWMutex
+	subscriptions map[string]map[string]bool
+	wanted        map[string]bool
+}
+
+func NewDispatcher(store Store, cfg config.Webhooks) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout.Std()}
+	if !cfg.AllowPrivateNetworks {
+		dialer.Control = publicOnly
+	}
+	return &Dispatcher{
+		store: store,
+		cfg:   cfg,
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout.Std(),
+				MaxIdleConnsPerHost: cfg.Workers,
+				IdleConnTimeout:     time.Minute,
+			},
+			Timeout: cfg.Timeout.Std(),
+			// A redirect is a failed delivery: following it would send the
+			// signed payload somewhere the owner did not register.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		idle:          make(chan struct{}, 1),
+		subscriptions: make(map[string]map[string]bool),
+		wanted:        make(map[string]bool),
+	}
+}
+
+// Subscribed reports whether an active webhook of forum listens to event.
+// A nil Dispatcher has no subscriptions.
+func (d *Dispatcher) Subscribed(forum, event string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.subscriptions[strings.ToLower(forum)][event]
+}
+
+// Wanted reports whether any active webhook listens to event.
+func (d *Dispatcher) Wanted(event string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.wanted[event]
+}
+
+// Refresh reloads the subscriptions. Call it after changing webhooks so
+// this instance sees the change at once.
+func (d *Dispatcher) Refresh(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	webhooks, err := d.store.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	subscriptions := make(map[string]map[string]bool)
+	wanted := make(map[string]bool)
+	for _, webhook := range webhooks {
+		forum := strings.ToLower(webhook.Forum)
+		if subscriptions[forum] == nil {
+			subscriptions[forum] = make(map[string]bool)
+		}
+		for _, event := range webhook.Events {
+			subscriptions[forum][event] = true
+			wanted[event] = true
+		}
+	}
+	d.mu.Lock()
+	d.subscriptions, d.wanted = subscriptions, wanted
+	d.mu.Unlock()
+	return nil
+}
+
+// Run sends deliveries until ctx is done and then waits for the ones in
+// flight. Deliveries cut short by the process exiting are retried once
+// their lease runs out.
+func (d *Dispatcher) Run(ctx context.Context) {
+	poll := time.NewTicker(d.cfg.PollInterval.Std())
+	defer poll.Stop()
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	if err := d.Refresh(ctx); err != nil {
+		log.Printf("load webhook subscriptions: %v", err)
+	}
+	for {
+		if d.dispatch(ctx) {
+			// All workers got a delivery, more are probably due.
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			d.wg.Wait()
+			return
+		case <-d.idle:
+		case <-poll.C:
+			if err := d.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("load webhook subscriptions: %v", err)
+			}
+		case <-purge.C:
+			d.purge(ctx)
+		}
+	}
+}
+
+// dispatch claims a due delivery for every free worker and reports whether
+// there were enough to keep all of them busy.
+func (d *Dispatcher) dispatch(ctx context.Context) bool {
+	free := d.cfg.Workers - int(d.busy.Load())
+	if free <= 0 || ctx.Err() != nil {
+		return false
+	}
+	// The lease outlasts the attempt, so a delivery is only claimed again
+	// when the instance sending it is gone.
+	jobs, err := d.store.ClaimWebhookDeliveries(ctx, free, 2*d.cfg.Timeout.Std())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("claim webhook deliveries: %v", err)
+		}
+		return false
+	}
+	for _, job := range jobs {
+		d.busy.Add(1)
+		d.wg.Add(1)
+		go func(job Job) {
+			defer func() {
+				d.busy.Add(-1)
+				d.wg.Done()
+				select {
+				case d.idle <- struct{}{}:
+				default:
+				}
+			}()
+			d.deliver(job)
+		}(job)
+	}
+	return len(jobs) == free
+}
+
+func (d *Dispatcher) deliver(job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.cfg.Timeout.Std())
+	status, err := d.send(ctx, job)
+	cancel()
+
+	result := Result{Status: models.DeliveryDelivered, LastStatus: status, NextAttempt: time.Now()}
+	switch attempt := job.Attempts + 1; {
+	case err == nil:
+		metrics.WebhookDeliveries.WithLabelValues(models.DeliveryDelivered).Inc()
+	case attempt >= d.cfg.MaxAttempts:
+		result.Status = models.DeliveryDead
+		result.LastError = truncate(err.Error())
+		metrics.WebhookDeliveries.WithLabelValues(models.DeliveryDead).Inc()
+		log.Printf("webhook %d delivery %d dead after %d attempts: %v", job.Webhook, job.ID, attempt, err)
+	default:
+		result.Status = models.DeliveryPending
+		result.LastError = truncate(err.Error())
+		result.NextAttempt = time.Now().Add(d.backoff(attempt))
+		metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), completeTimeout)
+	defer cancel()
+	if err = d.store.CompleteWebhookDelivery(ctx, job.ID, result); err != nil {
+		log.Printf("record webhook delivery %d: %v", job.ID, err)
+	}
+}
+
+// send posts the payload and returns the response status; any status
+// outside 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, job Job) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "forum-webhooks")
+	request.Header.Set(HeaderEvent, job.Event)
+	request.Header.Set(HeaderDelivery, strconv.FormatInt(job.ID, 10))
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, job.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("HTTP %d: %s", response.StatusCode, bytes.TrimSpace(body))
+	}
+	return response.StatusCode, nil
+}
+
+// backoff is the wait before the attempt after the given one: BackoffBase
+// doubling with every attempt up to BackoffMax, of which a random half is
+// taken off so failing deliveries do not retry in lockstep.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.BackoffMax.Std()
+	if base := d.cfg.BackoffBase.Std(); attempt <= 30 && base<<(attempt-1) < wait {
+		wait = base << (attempt - 1)
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+func (d *Dispatcher) purge(ctx context.Context) {
+	purged, err := d.store.PurgeWebhookDeliveries(ctx, time.Now().Add(-d.cfg.Retention.Std()))
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("purge webhook deliveries: %v", err)
+		}
+		return
+	}
+	if purged > 0 {
+		log.Printf("purged %d webhook deliveries", purged)
+	}
+}
+
+// Sign returns the X-Forum-Signature value of a delivery body sent at
+// timestamp.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicOnly refuses connections to loopback, private, link-local and
+// unspecified addresses, checked after name resolution so a public name
+// pointing at an internal address is refused as well.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return errors.New("webhook address " + host + " is not public")
+	}
+	return nil
+}
+
+// truncate shortens an error for the delivery log, which only takes valid
+// UTF-8 without NUL bytes; a response body may be anything.
+func truncate(message string) string {
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength] + "..."
+	}
+	return strings.ReplaceAll(strings.ToValidUTF8(message, ""), "\x00", "")
+}
@@ -0,0 +1,381 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/config"
+	"github.com/qqq4u/TP-DBMS-TermProject/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is the delivery queue of the repository kept in memory, with
+// the same claim and complete semantics as its queries.
+type memStore struct {
+	mu         sync.Mutex
+	url        string
+	secret     string
+	deliveries map[int64]*models.WebhookDelivery
+	// results are the completed attempts in order.
+	results []Result
+	done    chan int64
+}
+
+func newMemStore(url, secret string, deliveries ...models.WebhookDelivery) *memStore {
+	store := &memStore{url: url, secret: secret, deliveries: make(map[int64]*models.WebhookDelivery), done: make(chan int64, 16)}
+	now := time.Now()
+	for i := range deliveries {
+		delivery := deliveries[i]
+		delivery.Status = models.DeliveryPending
+		delivery.NextAttempt = &now
+		delivery.Created = now
+		store.deliveries[delivery.ID] = &delivery
+	}
+	return store
+}
+
+func (s *memStore) GetWebhookSubscriptions(ctx context.Context) ([]models.Webhook, error) {
+	return nil, nil
+}
+
+func (s *memStore) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []Job
+	now := time.Now()
+	for _, delivery := range s.deliveries {
+		if len(jobs) == limit {
+			break
+		}
+		if delivery.Status != models.DeliveryPending || delivery.NextAttempt.After(now) {
+			continue
+		}
+		leased := now.Add(lease)
+		delivery.NextAttempt = &leased
+		jobs = append(jobs, Job{WebhookDelivery: *delivery, URL: s.url, Secret: s.secret})
+	}
+	return jobs, nil
+}
+
+func (s *memStore) CompleteWebhookDelivery(ctx context.Context, id int64, result Result) error {
+	s.mu.Lock()
+	s.results = append(s.results, result)
+	delivery := s.deliveries[id]
+	delivery.Status = result.Status
+	delivery.Attempts++
+	delivery.LastStatus = result.LastStatus
+	delivery.LastError = result.LastError
+	nextAttempt := result.NextAttempt
+	delivery.NextAttempt = &nextAttempt
+	if result.Status == models.DeliveryDelivered {
+		now := time.Now()
+		delivery.Delivered = &now
+	}
+	status := delivery.Status
+	s.mu.Unlock()
+	if status != models.DeliveryPending {
+		s.done <- id
+	}
+	return nil
+}
+
+func (s *memStore) PurgeWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// delivery is a copy of the log entry of id.
+func (s *memStore) delivery(id int64) models.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.deliveries[id]
+}
+
+// receiver records the requests it gets and answers each with respond.
+type receiver struct {
+	mu       sync.Mutex
+	attempts []time.Time
+	requests []*http.Request
+	bodies   [][]byte
+	respond  func(attempt int, w http.ResponseWriter)
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	rc.attempts = append(rc.attempts, time.Now())
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	attempt := len(rc.attempts)
+	rc.mu.Unlock()
+	rc.respond(attempt, w)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.attempts)
+}
+
+func testConfig() config.Webhooks {
+	return config.Webhooks{
+		Enabled:              true,
+		Workers:              2,
+		PollInterval:         config.Duration(10 * time.Millisecond),
+		Timeout:              config.Duration(200 * time.Millisecond),
+		MaxAttempts:          3,
+		BackoffBase:          config.Duration(40 * time.Millisecond),
+		BackoffMax:           config.Duration(time.Second),
+		Retention:            config.Duration(time.Hour),
+		AllowPrivateNetworks: true,
+	}
+}
+
+// run dispatches until the delivery id leaves the pending state and
+// returns its log entry.
+func run(t *testing.T, cfg config.Webhooks, store *memStore, id int64) models.WebhookDelivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		NewDispatcher(store, cfg).Run(ctx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	select {
+	case done := <-store.done:
+		if done != id {
+			t.Fatalf("delivery %d completed, want %d", done, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("delivery %d still pending: %+v", id, store.delivery(id))
+	}
+	return store.delivery(id)
+}
+
+var payload = json.RawMessage(`{"event":"post.created","forum":"pirate-stories","data":{"id":1337,"message":"Yo ho"}}`)
+
+func TestDeliverySignature(t *testing.T) {
+	const secret = "dead men tell no tales"
+	rc := &receiver{respond: func(int, http.ResponseWriter) {}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	store := newMemStore(server.URL, secret, models.WebhookDelivery{ID: 7, Webhook: 3, Event: "post.created", Payload: payload})
+
+	delivery := run(t, testConfig(), store, 7)
+	if delivery.Status != models.DeliveryDelivered || delivery.LastStatus != http.StatusOK || delivery.Attempts != 1 {
+		t.Errorf("delivery log %+v, want delivered with 200 on the first attempt", delivery)
+	}
+	if delivery.Delivered == nil {
+		t.Error("delivered time is not set")
+	}
+
+	r, body := rc.requests[0], rc.bodies[0]
+	if string(body) != string(payload) {
+		t.Errorf("body %s, want %s", body, payload)
+	}
+	if got := r.Header.Get(HeaderEvent); got != "post.created" {
+		t.Errorf("%s %q", HeaderEvent, got)
+	}
+	if got := r.Header.Get(HeaderDelivery); got != "7" {
+		t.Errorf("%s %q", HeaderDelivery, got)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("%s: %v", HeaderTimestamp, err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age < -time.Second || age > 5*time.Second {
+		t.Errorf("%s is %s old", HeaderTimestamp, age)
+	}
+
+	// Verify the way a receiver would, without Sign.
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Header.Get(HeaderTimestamp) + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := r.Header.Get(HeaderSignature); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("%s %q, want %q", HeaderSignature, got, want)
+	}
+	if Sign("another secret", timestamp, body) == want {
+		t.Error("the signature does not depend on the secret")
+	}
+	if Sign(secret, timestamp+1, body) == want {
+		t.Error("the signature does not depend on the timestamp")
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	cfg := testConfig()
+	cases := []struct {
+		name    string
+		respond func(attempt int, w http.ResponseWriter)
+		failure string
+	}{
+		{"5xx", func(attempt int, w http.ResponseWriter) {
+			if attempt < cfg.MaxAttempts {
+				http.Error(w, "the kraken ate the server", http.StatusServiceUnavailable)
+			}
+		}, "HTTP 503: the kraken ate the server"},
+		{"timeout", func(attempt int, w http.ResponseWriter) {
+			if attempt < cfg.MaxAttempts {
+				time.Sleep(cfg.Timeout.Std() + 100*time.Millisecond)
+			}
+		}, "deadline exceeded"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rc := &receiver{respond: tc.respond}
+			server := httptest.NewServer(rc)
+			defer server.Close()
+			store := newMemStore(server.URL, "secret", models.WebhookDelivery{ID: 1, Webhook: 1, Event: "post.created", Payload: payload})
+
+			delivery := run(t, cfg, store, 1)
+			if delivery.Status != models.DeliveryDelivered || delivery.Attempts != cfg.MaxAttempts || delivery.LastStatus != http.StatusOK {
+				t.Errorf("delivery log %+v, want delivered with 200 on attempt %d", delivery, cfg.MaxAttempts)
+			}
+			if got := rc.count(); got != cfg.MaxAttempts {
+				t.Errorf("receiver got %d attempts, want %d", got, cfg.MaxAttempts)
+			}
+
+			// Each retry waits at least half of the doubled backoff after
+			// the failed attempt ended.
+			rc.mu.Lock()
+			defer rc.mu.Unlock()
+			for attempt := 1; attempt < len(rc.attempts); attempt++ {
+				minWait := (cfg.BackoffBase.Std() << (attempt - 1)) / 2
+				if gap := rc.attempts[attempt].Sub(rc.attempts[attempt-1]); gap < minWait {
+					t.Errorf("attempt %d came %s after the previous one, want at least %s", attempt+1, gap, minWait)
+				}
+			}
+
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			for i, result := range store.results[:len(store.results)-1] {
+				if result.Status != models.DeliveryPending || !strings.Contains(result.LastError, tc.failure) {
+					t.Errorf("attempt %d logged %q %q, want pending with %q", i+1, result.Status, result.LastError, tc.failure)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliveryDeadLetter(t *testing.T) {
+	cfg := testConfig()
+	rc := &receiver{respond: func(_ int, w http.ResponseWriter) {
+		http.Error(w, "walk the plank", http.StatusInternalServerError)
+	}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	store := newMemStore(server.URL, "secret", models.WebhookDelivery{ID: 9, Webhook: 1, Event: "thread.created", Payload: payload})
+
+	delivery := run(t, cfg, store, 9)
+	if delivery.Status != models.DeliveryDead {
+		t.Errorf("status %q, want %q", delivery.Status, models.DeliveryDead)
+	}
+	if delivery.Attempts != cfg.MaxAttempts {
+		t.Errorf("%d attempts, want %d", delivery.Attempts, cfg.MaxAttempts)
+	}
+	if delivery.LastStatus != http.StatusInternalServerError || delivery.LastError != "HTTP 500: walk the plank" {
+		t.Errorf("last status %d, last error %q", delivery.LastStatus, delivery.LastError)
+	}
+	if delivery.Delivered != nil {
+		t.Error("a dead delivery has a delivered time")
+	}
+
+	// A dead delivery is never claimed again.
+	time.Sleep(5 * cfg.PollInterval.Std())
+	if got := rc.count(); got != cfg.MaxAttempts {
+		t.Errorf("receiver got %d attempts, want %d", got, cfg.MaxAttempts)
+	}
+}
+
+func TestDeliveryLogPending(t *testing.T) {
+	cfg := testConfig()
+	cfg.BackoffBase = config.Duration(time.Hour)
+	cfg.BackoffMax = config.Duration(time.Hour)
+	rc := &receiver{respond: func(_ int, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusBadGateway)
+	}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+	store := newMemStore(server.URL, "secret", models.WebhookDelivery{ID: 2, Webhook: 1, Event: "post.created", Payload: payload})
+
+	d := NewDispatcher(store, cfg)
+	jobs, err := store.ClaimWebhookDeliveries(context.Background(), 1, time.Minute)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs: %v", len(jobs), err)
+	}
+	before := time.Now()
+	d.deliver(jobs[0])
+
+	delivery := store.delivery(2)
+	if delivery.Status != models.DeliveryPending || delivery.Attempts != 1 {
+		t.Errorf("delivery log %+v, want pending after one attempt", delivery)
+	}
+	if delivery.LastStatus != http.StatusBadGateway || delivery.LastError != "HTTP 502: " {
+		t.Errorf("last status %d, last error %q", delivery.LastStatus, delivery.LastError)
+	}
+	if wait := delivery.NextAttempt.Sub(before); wait < 30*time.Minute || wait > time.Hour+time.Second {
+		t.Errorf("next attempt in %s, want between half and all of the backoff", wait)
+	}
+}
+
+func TestDeliveryUnreachable(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxAttempts = 1
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+	store := newMemStore(url, "secret", models.WebhookDelivery{ID: 4, Webhook: 1, Event: "post.created", Payload: payload})
+
+	delivery := run(t, cfg, store, 4)
+	if delivery.Status != models.DeliveryDead || delivery.LastStatus != 0 || delivery.LastError == "" {
+		t.Errorf("delivery log %+v, want dead with no status and the connection error", delivery)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{cfg: testConfig()}
+	base, max := d.cfg.BackoffBase.Std(), d.cfg.BackoffMax.Std()
+	for attempt := 1; attempt <= 40; attempt++ {
+		want := max
+		if attempt <= 30 && base<<(attempt-1) < max {
+			want = base << (attempt - 1)
+		}
+		for i := 0; i < 20; i++ {
+			if got := d.backoff(attempt); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestPublicOnly(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34:443":  true,
+		"127.0.0.1:80":       false,
+		"10.1.2.3:80":        false,
+		"192.168.0.1:80":     false,
+		"169.254.169.254:80": false,
+		"[::1]:80":           false,
+		"0.0.0.0:80":         false,
+		"[2606:2800::1]:80":  true,
+	}
+	for address, allowed := range cases {
+		if err := publicOnly("tcp", address, nil); (err == nil) != allowed {
+			t.Errorf("publicOnly(%s) = %v, want allowed %t", address, err, allowed)
+		}
+	}
+}